@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+)
+
+// defaultAdmissionRetryInterval is the default interval between admission attempts.
+const defaultAdmissionRetryInterval = 100 * time.Millisecond
+
+// AdmissionConfig - settings of the transport-neutral admission gate (Service.Admit, Service.WaitUntilBelow).
+type AdmissionConfig struct {
+	// RetryInterval - interval between admission attempts and memory budget utilization checks.
+	// Zero means default value (100ms).
+	RetryInterval duration.Duration `json:"retry_interval"`
+}
+
+// Prepare - config validator.
+func (c *AdmissionConfig) Prepare() error {
+	if c.RetryInterval.Duration < 0 {
+		return errors.New("negative RetryInterval")
+	}
+
+	if c.RetryInterval.Duration == 0 {
+		c.RetryInterval.Duration = defaultAdmissionRetryInterval
+	}
+
+	return nil
+}
+
+// admissionGate admits units of work that are not served by middleware (queue consumers, batch jobs etc.).
+// It is safe for concurrent use.
+type admissionGate struct {
+	operator backpressure.Operator
+	// utilization returns the actual memory budget utilization.
+	utilization   func() (float64, error)
+	retryInterval time.Duration
+	// shadow makes gate always admit work, counting refusals only.
+	shadow bool
+
+	admitted    atomic.Uint64
+	throttled   atomic.Uint64
+	waiting     atomic.Int64
+	inFlight    atomic.Int64
+	shadowWaits atomic.Uint64
+}
+
+func newAdmissionGate(
+	operator backpressure.Operator,
+	utilization func() (float64, error),
+	cfg *AdmissionConfig,
+	shadow bool,
+) *admissionGate {
+	retryInterval := defaultAdmissionRetryInterval
+	if cfg != nil && cfg.RetryInterval.Duration > 0 {
+		retryInterval = cfg.RetryInterval.Duration
+	}
+
+	return &admissionGate{
+		operator:      operator,
+		utilization:   utilization,
+		retryInterval: retryInterval,
+		shadow:        shadow,
+	}
+}
+
+// admit blocks until the work is admitted by backpressure operator or the context is done.
+// Only the first attempt is registered in the throttling statistics, since the work is a single request.
+func (g *admissionGate) admit(ctx context.Context) (func(), error) {
+	g.waiting.Add(1)
+	defer g.waiting.Add(-1)
+
+	registered := false
+
+	err := g.poll(ctx, func() (bool, error) {
+		var allowed bool
+
+		if registered {
+			allowed = backpressure.CheckRequest(g.operator)
+		} else {
+			allowed, registered = g.operator.AllowRequest(), true
+		}
+
+		if allowed {
+			return true, nil
+		}
+
+		g.throttled.Add(1)
+
+		return g.shadow, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("admit: %w", err)
+	}
+
+	g.admitted.Add(1)
+	g.inFlight.Add(1)
+
+	var released atomic.Bool
+
+	return func() {
+		if released.CompareAndSwap(false, true) {
+			g.inFlight.Add(-1)
+		}
+	}, nil
+}
+
+// waitUntilBelow blocks until memory budget utilization falls below the level or the context is done.
+// In the shadow mode it never blocks, counting the waits that would have happened.
+func (g *admissionGate) waitUntilBelow(ctx context.Context, level float64) error {
+	if g.shadow {
+		utilization, err := g.utilization()
+		if err != nil {
+			return fmt.Errorf("wait until below %v: %w", level, err)
+		}
+
+		if utilization >= level {
+			g.shadowWaits.Add(1)
+		}
+
+		return nil
+	}
+
+	g.waiting.Add(1)
+	defer g.waiting.Add(-1)
+
+	err := g.poll(ctx, func() (bool, error) {
+		utilization, err := g.utilization()
+		if err != nil {
+			return false, err
+		}
+
+		return utilization < level, nil
+	})
+	if err != nil {
+		return fmt.Errorf("wait until below %v: %w", level, err)
+	}
+
+	return nil
+}
+
+// poll repeats the check until it succeeds or the context is done.
+func (g *admissionGate) poll(ctx context.Context, check func() (bool, error)) error {
+	timer := time.NewTimer(g.retryInterval)
+	defer timer.Stop()
+
+	for {
+		ok, err := check()
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return nil
+		}
+
+		timer.Reset(g.retryInterval)
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-timer.C:
+		}
+	}
+}
+
+// getStats returns admission gate statistics.
+func (g *admissionGate) getStats() *stats.AdmissionStats {
+	return &stats.AdmissionStats{
+		Admitted:    g.admitted.Load(),
+		Throttled:   g.throttled.Load(),
+		Waiting:     g.waiting.Load(),
+		InFlight:    g.inFlight.Load(),
+		ShadowWaits: g.shadowWaits.Load(),
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+	"github.com/stretchr/testify/require"
+)
+
+// checkingOperatorMock supports decisions that are not registered in the throttling statistics.
+type checkingOperatorMock struct {
+	backpressure.OperatorMock
+}
+
+func (m *checkingOperatorMock) CheckRequest() bool {
+	args := m.Called()
+
+	return args.Bool(0)
+}
+
+func TestAdmissionGate(t *testing.T) {
+	cfg := &AdmissionConfig{RetryInterval: duration.Duration{Duration: time.Millisecond}}
+	require.NoError(t, cfg.Prepare())
+
+	t.Run("admit after throttling", func(t *testing.T) {
+		// Only the first attempt is registered in the throttling statistics.
+		operator := &checkingOperatorMock{}
+		operator.On("AllowRequest").Return(false).Once()
+		operator.On("CheckRequest").Return(false).Once()
+		operator.On("CheckRequest").Return(true).Once()
+
+		gate := newAdmissionGate(operator, nil, cfg, false)
+
+		release, err := gate.admit(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), gate.getStats().InFlight)
+
+		release()
+		release()
+
+		admissionStats := gate.getStats()
+		require.Equal(t, int64(0), admissionStats.InFlight)
+		require.Equal(t, uint64(1), admissionStats.Admitted)
+		require.Equal(t, uint64(2), admissionStats.Throttled)
+		operator.AssertExpectations(t)
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		operator := &backpressure.OperatorMock{}
+		operator.On("AllowRequest").Return(false)
+
+		gate := newAdmissionGate(operator, nil, cfg, false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		release, err := gate.admit(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Nil(t, release)
+		require.Equal(t, uint64(0), gate.getStats().Admitted)
+	})
+
+	t.Run("shadow mode", func(t *testing.T) {
+		operator := &backpressure.OperatorMock{}
+		operator.On("AllowRequest").Return(false).Once()
+
+		gate := newAdmissionGate(operator, nil, cfg, true)
+
+		release, err := gate.admit(context.Background())
+		require.NoError(t, err)
+		release()
+		require.Equal(t, uint64(1), gate.getStats().Throttled)
+	})
+
+	t.Run("wait until below", func(t *testing.T) {
+		var utilization atomic.Int64
+
+		utilization.Store(95)
+
+		gate := newAdmissionGate(
+			nil,
+			func() (float64, error) {
+				// Utilization decreases with every check.
+				return float64(utilization.Add(-5)) / 100, nil
+			},
+			cfg,
+			false,
+		)
+
+		require.NoError(t, gate.waitUntilBelow(context.Background(), 0.8))
+		require.Equal(t, int64(75), utilization.Load())
+	})
+
+	t.Run("wait until below in shadow mode", func(t *testing.T) {
+		gate := newAdmissionGate(nil, func() (float64, error) { return 0.95, nil }, cfg, true)
+
+		require.NoError(t, gate.waitUntilBelow(context.Background(), 0.8))
+		require.NoError(t, gate.waitUntilBelow(context.Background(), 0.99))
+		require.Equal(t, uint64(1), gate.getStats().ShadowWaits)
+	})
+}
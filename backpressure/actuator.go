@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"sync"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+// actuator decides which value of a single control parameter has to be applied,
+// taking into account bounds, rate of change and deadband.
+// It is safe for concurrent use.
+type actuator struct {
+	// cfg is the actuator configuration.
+	cfg *ActuatorConfig
+	// neutral is the value corresponding to the absence of backpressure.
+	neutral int
+	// value is the currently applied value.
+	value int
+	// initialized is set after the first update.
+	initialized bool
+	// updates is the number of updates that changed the applied value.
+	updates uint64
+	// suppressed is the number of changes ignored because of deadband.
+	suppressed uint64
+	// mutex protects the state.
+	mutex sync.Mutex
+}
+
+// newActuator creates a new actuator. Nil config means default settings.
+func newActuator(cfg *ActuatorConfig, neutral int) *actuator {
+	if cfg == nil {
+		cfg = &ActuatorConfig{Max: defaultActuatorMax}
+	}
+
+	return &actuator{
+		cfg:     cfg,
+		neutral: neutral,
+		value:   neutral,
+	}
+}
+
+// Rules applied by actuator (see AuditParameter.Rules).
+const (
+	// ControlRuleActuatorDisabled - actuator is disabled, so the value is never changed.
+	ControlRuleActuatorDisabled stats.ControlRule = "actuator_disabled"
+	// ControlRuleActuatorBounds - target value has been clamped to actuator bounds.
+	ControlRuleActuatorBounds stats.ControlRule = "actuator_bounds"
+	// ControlRuleActuatorDeadband - the change has been ignored because of deadband.
+	ControlRuleActuatorDeadband stats.ControlRule = "actuator_deadband"
+	// ControlRuleActuatorMaxStep - the change has been limited with the maximal step.
+	ControlRuleActuatorMaxStep stats.ControlRule = "actuator_max_step"
+)
+
+// next returns the value that has to be applied for the target value requested by controller,
+// and whether this value differs from the currently applied one.
+func (a *actuator) next(target int) (int, bool) {
+	value, changed, _ := a.nextWithRules(target)
+
+	return value, changed
+}
+
+// nextWithRules works like next, but also reports the rules that have affected the value.
+func (a *actuator) nextWithRules(target int) (int, bool, []stats.ControlRule) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.cfg.Disabled {
+		return a.value, false, []stats.ControlRule{ControlRuleActuatorDisabled}
+	}
+
+	var rules []stats.ControlRule
+
+	if bounded := min(max(target, a.cfg.Min), a.cfg.Max); bounded != target {
+		target = bounded
+		rules = append(rules, ControlRuleActuatorBounds)
+	}
+
+	// The first value is applied as is.
+	if !a.initialized {
+		a.initialized = true
+		a.value = target
+		a.updates++
+
+		return a.value, true, rules
+	}
+
+	delta := target - a.value
+
+	if delta == 0 {
+		return a.value, false, rules
+	}
+
+	if a.cfg.Deadband > 0 && abs(delta) < a.cfg.Deadband && target != a.neutral {
+		a.suppressed++
+
+		return a.value, false, append(rules, ControlRuleActuatorDeadband)
+	}
+
+	if a.cfg.MaxStep > 0 && abs(delta) > a.cfg.MaxStep {
+		if delta > 0 {
+			delta = a.cfg.MaxStep
+		} else {
+			delta = -a.cfg.MaxStep
+		}
+
+		rules = append(rules, ControlRuleActuatorMaxStep)
+	}
+
+	a.value += delta
+	a.updates++
+
+	return a.value, true, rules
+}
+
+// applied returns the currently applied value.
+func (a *actuator) applied() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.value
+}
+
+// getStats returns actuator statistics.
+func (a *actuator) getStats() *stats.ActuatorStats {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return &stats.ActuatorStats{
+		Enabled:    !a.cfg.Disabled,
+		Value:      a.value,
+		Updates:    a.updates,
+		Suppressed: a.suppressed,
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActuator(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		a := newActuator(nil, DefaultGOGC)
+
+		value, changed := a.next(40)
+		require.True(t, changed)
+		require.Equal(t, 40, value)
+
+		value, changed = a.next(40)
+		require.False(t, changed)
+		require.Equal(t, 40, value)
+
+		value, changed = a.next(DefaultGOGC)
+		require.True(t, changed)
+		require.Equal(t, DefaultGOGC, value)
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		cfg := &ActuatorConfig{Min: 30, Max: 90}
+		require.NoError(t, cfg.Prepare())
+
+		a := newActuator(cfg, DefaultGOGC)
+
+		value, _ := a.next(10)
+		require.Equal(t, 30, value)
+
+		value, _ = a.next(DefaultGOGC)
+		require.Equal(t, 90, value)
+	})
+
+	t.Run("max step", func(t *testing.T) {
+		cfg := &ActuatorConfig{MaxStep: 10}
+		require.NoError(t, cfg.Prepare())
+
+		a := newActuator(cfg, NoThrottling)
+
+		value, changed := a.next(NoThrottling)
+		require.True(t, changed)
+		require.Equal(t, NoThrottling, value)
+
+		for _, expected := range []int{10, 20, 25, 25} {
+			value, _ = a.next(25)
+			require.Equal(t, expected, value)
+		}
+
+		value, _ = a.next(NoThrottling)
+		require.Equal(t, 15, value)
+	})
+
+	t.Run("deadband", func(t *testing.T) {
+		cfg := &ActuatorConfig{Deadband: 5}
+		require.NoError(t, cfg.Prepare())
+
+		a := newActuator(cfg, DefaultGOGC)
+
+		value, _ := a.next(50)
+		require.Equal(t, 50, value)
+
+		value, changed := a.next(53)
+		require.False(t, changed)
+		require.Equal(t, 50, value)
+
+		value, changed = a.next(56)
+		require.True(t, changed)
+		require.Equal(t, 56, value)
+
+		// Returning to the neutral value is never suppressed.
+		a = newActuator(cfg, DefaultGOGC)
+		_, _ = a.next(98)
+
+		value, changed = a.next(DefaultGOGC)
+		require.True(t, changed)
+		require.Equal(t, DefaultGOGC, value)
+
+		actuatorStats := a.getStats()
+		require.True(t, actuatorStats.Enabled)
+		require.Equal(t, uint64(2), actuatorStats.Updates)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		a := newActuator(&ActuatorConfig{Disabled: true}, NoThrottling)
+
+		value, changed := a.next(80)
+		require.False(t, changed)
+		require.Equal(t, NoThrottling, value)
+		require.False(t, a.getStats().Enabled)
+	})
+}
+
+func TestActuatorConfig(t *testing.T) {
+	cfg := &ActuatorConfig{}
+	require.NoError(t, cfg.Prepare())
+	require.Equal(t, defaultActuatorMax, cfg.Max)
+
+	for _, cfg := range []*ActuatorConfig{
+		{Min: -1},
+		{Max: 101},
+		{Min: 60, Max: 50},
+		{MaxStep: -1},
+		{Deadband: -1},
+	} {
+		require.Error(t, cfg.Prepare())
+	}
+}
+
+func TestOperatorActuators(t *testing.T) {
+	const expectedGOGC = 73
+
+	originalBeforeTest := debug.SetGCPercent(expectedGOGC)
+	defer debug.SetGCPercent(originalBeforeTest)
+
+	cfg := &Config{
+		GOGC:       &ActuatorConfig{Disabled: true},
+		Throttling: &ActuatorConfig{Max: 50},
+	}
+	require.NoError(t, cfg.GOGC.Prepare())
+	require.NoError(t, cfg.Throttling.Prepare())
+
+	op := NewOperator(testr.New(t), WithConfig(cfg))
+	defer op.Quit()
+
+	err := op.SetControlParameters(&stats.ControlParameters{
+		GOGC:                 20,
+		ThrottlingPercentage: 100,
+	})
+	require.NoError(t, err)
+
+	// GOGC actuator is disabled, so the runtime setting must stay intact.
+	require.Equal(t, expectedGOGC, debug.SetGCPercent(expectedGOGC))
+
+	backpressureStats, err := op.GetStats()
+	require.NoError(t, err)
+	require.False(t, backpressureStats.Actuators.GOGC.Enabled)
+	require.Equal(t, 50, backpressureStats.Actuators.Throttling.Value)
+}
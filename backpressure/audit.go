@@ -0,0 +1,318 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+// AuditConfig - settings of the control decisions audit (see WithAuditSink).
+type AuditConfig struct {
+	// Every - only every N-th control decision is emitted; decisions changing memory budget utilization zone
+	// are always emitted. Zero means that every decision is emitted.
+	Every int `json:"every"`
+}
+
+// Prepare - config validator.
+func (c *AuditConfig) Prepare() error {
+	if c.Every < 0 {
+		return errors.New("negative Every")
+	}
+
+	if c.Every == 0 {
+		c.Every = 1
+	}
+
+	return nil
+}
+
+// AuditRecord - control decision (made on every controller update, whether or not it changes
+// applied GOGC or throttling) with explanation.
+type AuditRecord struct {
+	// Time - the moment of the decision.
+	Time time.Time `json:"time"`
+	// Zone - memory budget utilization zone.
+	Zone stats.Zone `json:"zone"`
+	// Utilization - memory budget utilization ratio.
+	Utilization float64 `json:"utilization"`
+	// RSS - physical memory (RSS) current consumption [bytes].
+	RSS uint64 `json:"rss"`
+	// RSSLimit - physical memory (RSS) consumption limit [bytes].
+	RSSLimit uint64 `json:"rss_limit"`
+	// GoAllocLimit - allocation limit for Go Runtime [bytes].
+	GoAllocLimit uint64 `json:"go_alloc_limit"`
+	// CgoTotal - total memory consumed beyond the Cgo border [bytes].
+	CgoTotal uint64 `json:"cgo_total"`
+	// BudgetExhausted - Cgo consumers have exhausted the RSS limit.
+	BudgetExhausted bool `json:"budget_exhausted"`
+	// ComponentP - proportional component's output.
+	ComponentP float64 `json:"component_p"`
+	// Output - final controller output.
+	Output float64 `json:"output"`
+	// OutputSaturated - controller output has been clamped to the allowed range.
+	OutputSaturated bool `json:"output_saturated"`
+	// DangerZoneGOGC - utilization threshold enabling GOGC tuning [percents].
+	DangerZoneGOGC uint32 `json:"danger_zone_gogc"`
+	// DangerZoneThrottling - utilization threshold enabling throttling [percents].
+	DangerZoneThrottling uint32 `json:"danger_zone_throttling"`
+	// GOGC - GOGC decision.
+	GOGC *AuditParameter `json:"gogc"`
+	// Throttling - throttling percentage decision.
+	Throttling *AuditParameter `json:"throttling"`
+	// Shadow - decision has not been applied because of the shadow (dry-run) mode.
+	Shadow bool `json:"shadow"`
+	// Reason - human-readable explanation.
+	Reason string `json:"reason"`
+}
+
+// AuditParameter - decision on a single control parameter.
+type AuditParameter struct {
+	// Requested - value requested by controller.
+	Requested int `json:"requested"`
+	// Previous - previously applied value.
+	Previous int `json:"previous"`
+	// Applied - applied value.
+	Applied int `json:"applied"`
+	// Rules - controller and actuator rules that have determined the applied value.
+	Rules []stats.ControlRule `json:"rules"`
+}
+
+// AuditSink receives control decisions. Implementations are called synchronously from the controller loop,
+// so they must be fast.
+type AuditSink interface {
+	// WriteAuditRecord stores record.
+	WriteAuditRecord(record *AuditRecord) error
+}
+
+// AuditSinkFunc adapts function to AuditSink.
+type AuditSinkFunc func(record *AuditRecord) error
+
+// WriteAuditRecord calls f(record).
+func (f AuditSinkFunc) WriteAuditRecord(record *AuditRecord) error { return f(record) }
+
+// NewLogrAuditSink returns AuditSink writing records as structured log messages.
+func NewLogrAuditSink(logger logr.Logger) AuditSink {
+	return AuditSinkFunc(func(record *AuditRecord) error {
+		logger.Info(
+			"control decision",
+			"zone", record.Zone.String(),
+			"utilization", record.Utilization,
+			"rss", record.RSS,
+			"rss_limit", record.RSSLimit,
+			"go_alloc_limit", record.GoAllocLimit,
+			"cgo_total", record.CgoTotal,
+			"budget_exhausted", record.BudgetExhausted,
+			"component_p", record.ComponentP,
+			"output", record.Output,
+			"output_saturated", record.OutputSaturated,
+			"danger_zone_gogc", record.DangerZoneGOGC,
+			"danger_zone_throttling", record.DangerZoneThrottling,
+			"gogc", record.GOGC,
+			"throttling", record.Throttling,
+			"shadow", record.Shadow,
+			"reason", record.Reason,
+		)
+
+		return nil
+	})
+}
+
+// jsonlAuditSink writes records as JSON lines.
+type jsonlAuditSink struct {
+	encoder *json.Encoder
+	// mutex serializes writes.
+	mutex sync.Mutex
+}
+
+// NewJSONLAuditSink returns AuditSink writing records as JSON lines (for example, into *os.File).
+// Closing the writer is up to the caller.
+func NewJSONLAuditSink(w io.Writer) AuditSink {
+	return &jsonlAuditSink{encoder: json.NewEncoder(w)}
+}
+
+func (s *jsonlAuditSink) WriteAuditRecord(record *AuditRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.encoder.Encode(record); err != nil {
+		return fmt.Errorf("encode record: %w", err)
+	}
+
+	return nil
+}
+
+// auditor builds audit records and samples them.
+type auditor struct {
+	// sink receives sampled records; may be nil.
+	sink AuditSink
+	// fullSinks receive every record.
+	fullSinks []AuditSink
+	every     int
+	// skipped is the number of records skipped since the latest emitted one.
+	skipped int
+	// lastZone is the zone of the latest decision.
+	lastZone stats.Zone
+	// zoneKnown is set after the first decision.
+	zoneKnown bool
+	// mutex protects the state.
+	mutex sync.Mutex
+}
+
+func newAuditor(sink AuditSink, fullSinks []AuditSink, cfg *AuditConfig) *auditor {
+	every := 1
+	if cfg != nil && cfg.Every > 0 {
+		every = cfg.Every
+	}
+
+	return &auditor{sink: sink, fullSinks: fullSinks, every: every}
+}
+
+// sample decides whether the record has to be emitted.
+func (a *auditor) sample(record *AuditRecord) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	zoneChanged := !a.zoneKnown || record.Zone != a.lastZone
+	a.lastZone, a.zoneKnown = record.Zone, true
+
+	if zoneChanged || a.skipped+1 >= a.every {
+		a.skipped = 0
+
+		return true
+	}
+
+	a.skipped++
+
+	return false
+}
+
+// emit builds the record and passes it to the full sinks and to the sink if it's sampled.
+func (a *auditor) emit(now time.Time, value *stats.ControlParameters, gogc, throttling *AuditParameter, shadow bool) error {
+	record := newAuditRecord(now, value, gogc, throttling, shadow)
+
+	var errs []error
+
+	for _, sink := range a.fullSinks {
+		if err := sink.WriteAuditRecord(record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if a.sink != nil && a.sample(record) {
+		if err := a.sink.WriteAuditRecord(record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("write audit record: %w", err)
+	}
+
+	return nil
+}
+
+// newAuditParameter combines controller rule with actuator rules.
+func newAuditParameter(requested, previous, applied int, rule stats.ControlRule, actuatorRules []stats.ControlRule) *AuditParameter {
+	out := &AuditParameter{Requested: requested, Previous: previous, Applied: applied}
+
+	if rule != "" {
+		out.Rules = append(out.Rules, rule)
+	}
+
+	out.Rules = append(out.Rules, actuatorRules...)
+
+	return out
+}
+
+func newAuditRecord(now time.Time, value *stats.ControlParameters, gogc, throttling *AuditParameter, shadow bool) *AuditRecord {
+	out := &AuditRecord{
+		Time:       now,
+		GOGC:       gogc,
+		Throttling: throttling,
+		Shadow:     shadow,
+	}
+
+	if explanation := value.Explanation; explanation != nil {
+		out.CgoTotal = explanation.CgoTotal
+		out.BudgetExhausted = explanation.BudgetExhausted
+		out.OutputSaturated = explanation.OutputSaturated
+		out.DangerZoneGOGC = explanation.DangerZoneGOGC
+		out.DangerZoneThrottling = explanation.DangerZoneThrottling
+	}
+
+	if cs := value.ControllerStats; cs != nil {
+		if cs.MemoryBudget != nil {
+			out.Zone = cs.MemoryBudget.Zone
+			out.Utilization = cs.MemoryBudget.Utilization
+			out.RSS = cs.MemoryBudget.RSSActual
+			out.RSSLimit = cs.MemoryBudget.RSSLimit
+			out.GoAllocLimit = cs.MemoryBudget.GoAllocLimit
+		}
+
+		if cs.NextGC != nil {
+			out.ComponentP = cs.NextGC.P
+			out.Output = cs.NextGC.Output
+		}
+	}
+
+	out.Reason = auditReason(out)
+
+	return out
+}
+
+// auditReason renders human-readable explanation of the decision.
+func auditReason(record *AuditRecord) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "utilization %.3f in %s zone", record.Utilization, record.Zone)
+
+	if record.BudgetExhausted {
+		sb.WriteString(" (Cgo consumers exhausted RSS limit)")
+	}
+
+	if record.OutputSaturated {
+		fmt.Fprintf(&sb, ", controller output saturated at %.1f", record.Output)
+	}
+
+	for _, item := range []struct {
+		name      string
+		parameter *AuditParameter
+	}{
+		{name: "GOGC", parameter: record.GOGC},
+		{name: "throttling", parameter: record.Throttling},
+	} {
+		if item.parameter == nil || item.parameter.Previous == item.parameter.Applied {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "; %s %d -> %d", item.name, item.parameter.Previous, item.parameter.Applied)
+
+		if item.parameter.Requested != item.parameter.Applied {
+			fmt.Fprintf(&sb, " (requested %d)", item.parameter.Requested)
+		}
+
+		if len(item.parameter.Rules) > 0 {
+			rules := make([]string, 0, len(item.parameter.Rules))
+			for _, rule := range item.parameter.Rules {
+				rules = append(rules, string(rule))
+			}
+
+			fmt.Fprintf(&sb, " by %s", strings.Join(rules, ", "))
+		}
+	}
+
+	return sb.String()
+}
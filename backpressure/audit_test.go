@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+)
+
+func auditedControlParameters(zone stats.Zone, gogc int, gogcRule stats.ControlRule, throttling uint32) *stats.ControlParameters {
+	throttlingRule := stats.ControlRuleOutput
+	if throttling == NoThrottling {
+		throttlingRule = stats.ControlRuleBelowDangerZone
+	}
+
+	return &stats.ControlParameters{
+		ControllerStats: &stats.ControllerStats{
+			MemoryBudget: &stats.MemoryBudgetStats{Zone: zone, Utilization: 0.95, RSSActual: 950, RSSLimit: 1000},
+			NextGC:       &stats.ControllerNextGCStats{P: 60, Output: 60},
+		},
+		GOGC:                 gogc,
+		ThrottlingPercentage: throttling,
+		Explanation: &stats.ControlExplanation{
+			CgoTotal:             100,
+			DangerZoneGOGC:       50,
+			DangerZoneThrottling: 90,
+			GOGCRule:             gogcRule,
+			ThrottlingRule:       throttlingRule,
+		},
+	}
+}
+
+func TestOperatorAudit(t *testing.T) {
+	var records []*AuditRecord
+
+	sink := AuditSinkFunc(func(record *AuditRecord) error {
+		records = append(records, record)
+
+		return nil
+	})
+
+	cfg := &Config{
+		Throttling: &ActuatorConfig{MaxStep: 10},
+		Audit:      &AuditConfig{Every: 2},
+	}
+	require.NoError(t, cfg.Throttling.Prepare())
+	require.NoError(t, cfg.Audit.Prepare())
+
+	var fullRecords []*AuditRecord
+
+	fullSink := AuditSinkFunc(func(record *AuditRecord) error {
+		fullRecords = append(fullRecords, record)
+
+		return nil
+	})
+
+	op := NewOperator(testr.New(t), WithShadowMode(), WithConfig(cfg), WithAuditSink(sink), WithFullAuditSink(fullSink))
+
+	steps := []*stats.ControlParameters{
+		auditedControlParameters(stats.ZoneThrottling, 40, stats.ControlRuleOutput, 30),
+		// skipped by sampling
+		auditedControlParameters(stats.ZoneThrottling, 40, stats.ControlRuleOutput, 50),
+		auditedControlParameters(stats.ZoneThrottling, 10, stats.ControlRuleMinGOGC, 60),
+		// zone change is never skipped
+		auditedControlParameters(stats.ZoneGreen, DefaultGOGC, stats.ControlRuleBelowDangerZone, NoThrottling),
+	}
+
+	for _, step := range steps {
+		require.NoError(t, op.SetControlParameters(step))
+	}
+
+	require.Len(t, records, 3)
+	// full sink isn't affected by sampling
+	require.Len(t, fullRecords, len(steps))
+
+	first := records[0]
+	require.Equal(t, stats.ZoneThrottling, first.Zone)
+	require.Equal(t, uint64(950), first.RSS)
+	require.Equal(t, uint64(100), first.CgoTotal)
+	require.Equal(t, uint32(50), first.DangerZoneGOGC)
+	require.Equal(t, uint32(90), first.DangerZoneThrottling)
+	require.True(t, first.Shadow)
+	require.Equal(t, &AuditParameter{
+		Requested: 30,
+		Previous:  NoThrottling,
+		Applied:   30,
+		Rules:     []stats.ControlRule{stats.ControlRuleOutput},
+	}, first.Throttling)
+
+	second := records[1]
+	require.Equal(t, &AuditParameter{
+		Requested: 60,
+		Previous:  40,
+		Applied:   50,
+		Rules:     []stats.ControlRule{stats.ControlRuleOutput, ControlRuleActuatorMaxStep},
+	}, second.Throttling)
+	require.Equal(t, []stats.ControlRule{stats.ControlRuleMinGOGC}, second.GOGC.Rules)
+	require.Equal(t,
+		"utilization 0.950 in throttling zone; GOGC 40 -> 10 by min_gogc; "+
+			"throttling 40 -> 50 (requested 60) by controller_output, actuator_max_step",
+		second.Reason,
+	)
+
+	third := records[2]
+	require.Equal(t, stats.ZoneGreen, third.Zone)
+	require.Equal(t, 40, third.Throttling.Applied)
+	require.Equal(t,
+		[]stats.ControlRule{stats.ControlRuleBelowDangerZone, ControlRuleActuatorMaxStep},
+		third.Throttling.Rules,
+	)
+}
+
+func TestOperatorAuditUnchangedDecisions(t *testing.T) {
+	var records []*AuditRecord
+
+	sink := AuditSinkFunc(func(record *AuditRecord) error {
+		records = append(records, record)
+
+		return nil
+	})
+
+	op := NewOperator(testr.New(t), WithShadowMode(), WithAuditSink(sink))
+
+	// the second decision keeps applied values intact, but it's audited anyway
+	for range 2 {
+		require.NoError(t, op.SetControlParameters(
+			auditedControlParameters(stats.ZoneThrottling, 40, stats.ControlRuleOutput, 30),
+		))
+	}
+
+	require.Len(t, records, 2)
+	require.Equal(t, &AuditParameter{
+		Requested: 30,
+		Previous:  30,
+		Applied:   30,
+		Rules:     []stats.ControlRule{stats.ControlRuleOutput},
+	}, records[1].Throttling)
+}
+
+func TestJSONLAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+
+	sink := NewJSONLAuditSink(&buf)
+
+	for range 2 {
+		require.NoError(t, sink.WriteAuditRecord(&AuditRecord{
+			Zone:   stats.ZoneCritical,
+			GOGC:   &AuditParameter{Requested: 10, Previous: 20, Applied: 10},
+			Reason: "test",
+		}))
+	}
+
+	decoder := json.NewDecoder(&buf)
+
+	for range 2 {
+		var record AuditRecord
+		require.NoError(t, decoder.Decode(&record))
+		require.Equal(t, stats.ZoneCritical, record.Zone)
+		require.Equal(t, 10, record.GOGC.Applied)
+	}
+
+	require.False(t, decoder.More())
+}
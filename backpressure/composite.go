@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+var (
+	_ Operator         = (*compositeOperator)(nil)
+	_ RequestChecker   = (*compositeOperator)(nil)
+	_ PressureReporter = (*compositeOperator)(nil)
+)
+
+// compositeOperator fans out control signals to several operators.
+type compositeOperator struct {
+	// throttler only accounts the combined decisions, it never makes decisions itself.
+	throttler *throttler
+	// operators are the child operators in the order of registration.
+	operators []Operator
+}
+
+// NewCompositeOperator constructs an Operator forwarding control signals to several operators.
+// It makes it possible to add application-specific backpressure actions (like shrinking caches)
+// while keeping the built-in GOGC tuning and throttling:
+//
+//	memlimiter.WithBackpressureOperator(backpressure.NewCompositeOperator(backpressure.NewOperator(logger), custom))
+//
+// The composite behaves as follows:
+//   - SetControlParameters is forwarded to every operator, errors are aggregated;
+//   - AllowRequest allows request only if every operator allows it; operators are asked in the order
+//     of registration until the first refusal; CheckRequest does the same without updating statistics;
+//   - Pressure is taken from the first operator that implements PressureReporter;
+//   - GetStats takes every section (like control parameters) from the first operator that reports it,
+//     while throttling statistics reflect the combined decisions made by the composite;
+//   - Quit terminates operators in the reverse order of registration, so the first operator
+//     (which is usually the one restoring runtime settings) quits last.
+func NewCompositeOperator(operators ...Operator) Operator {
+	return &compositeOperator{
+		throttler: newThrottler(),
+		operators: operators,
+	}
+}
+
+// SetControlParameters forwards the control parameters to all the operators.
+func (c *compositeOperator) SetControlParameters(value *stats.ControlParameters) error {
+	var errs []error
+
+	for i, op := range c.operators {
+		if err := op.SetControlParameters(value); err != nil {
+			errs = append(errs, fmt.Errorf("operator #%d: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// AllowRequest allows request only if all the operators allow it.
+func (c *compositeOperator) AllowRequest() bool {
+	allowed := true
+
+	for _, op := range c.operators {
+		if !op.AllowRequest() {
+			allowed = false
+
+			break
+		}
+	}
+
+	c.throttler.register(allowed)
+
+	return allowed
+}
+
+// CheckRequest allows request only if all the operators allow it, but doesn't update statistics.
+func (c *compositeOperator) CheckRequest() bool {
+	for _, op := range c.operators {
+		if !CheckRequest(op) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Pressure returns memory pressure reported by the first operator implementing PressureReporter.
+func (c *compositeOperator) Pressure() Pressure {
+	for _, op := range c.operators {
+		if reporter, ok := op.(PressureReporter); ok {
+			return reporter.Pressure()
+		}
+	}
+
+	// Statistics of the operators that haven't failed are good enough.
+	backpressureStats, _ := c.GetStats()
+
+	return pressureFromStats(backpressureStats)
+}
+
+// GetStats combines the statistics of all the operators. If some of them fail,
+// the statistics of the other ones are returned along with the error.
+func (c *compositeOperator) GetStats() (*stats.BackpressureStats, error) {
+	var errs []error
+
+	out := &stats.BackpressureStats{
+		Throttling: c.throttler.getStats(),
+	}
+
+	for i, op := range c.operators {
+		opStats, err := op.GetStats()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("operator #%d: %w", i, err))
+
+			continue
+		}
+
+		out.Merge(opStats)
+	}
+
+	return out, errors.Join(errs...)
+}
+
+// Quit terminates all the operators in the reverse order.
+func (c *compositeOperator) Quit() {
+	for i := len(c.operators) - 1; i >= 0; i-- {
+		c.operators[i].Quit()
+	}
+}
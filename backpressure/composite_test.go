@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompositeOperator(t *testing.T) {
+	t.Run("set control parameters", func(t *testing.T) {
+		params := &stats.ControlParameters{GOGC: 50, ThrottlingPercentage: 10}
+
+		first, second, third := &OperatorMock{}, &OperatorMock{}, &OperatorMock{}
+		first.On("SetControlParameters", params).Return(errors.New("first failed"))
+		second.On("SetControlParameters", params).Return(nil)
+		third.On("SetControlParameters", params).Return(errors.New("third failed"))
+
+		op := NewCompositeOperator(first, second, third)
+
+		err := op.SetControlParameters(params)
+		require.ErrorContains(t, err, "operator #0: first failed")
+		require.ErrorContains(t, err, "operator #2: third failed")
+
+		first.AssertExpectations(t)
+		second.AssertExpectations(t)
+		third.AssertExpectations(t)
+	})
+
+	t.Run("allow request", func(t *testing.T) {
+		first, second := &OperatorMock{}, &OperatorMock{}
+		first.On("AllowRequest").Return(true).Twice()
+		second.On("AllowRequest").Return(true).Once()
+		second.On("AllowRequest").Return(false).Once()
+
+		op := NewCompositeOperator(first, second)
+		require.True(t, op.AllowRequest())
+		require.False(t, op.AllowRequest())
+
+		// Refusal of the first operator makes it unnecessary to ask the rest ones.
+		first.On("AllowRequest").Return(false).Once()
+		require.False(t, op.AllowRequest())
+
+		first.On("GetStats").Return(&stats.BackpressureStats{}, nil)
+		second.On("GetStats").Return(&stats.BackpressureStats{}, nil)
+
+		st, err := op.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), st.Throttling.Total)
+		require.Equal(t, uint64(1), st.Throttling.Passed)
+		require.Equal(t, uint64(2), st.Throttling.Throttled)
+
+		first.AssertExpectations(t)
+		second.AssertExpectations(t)
+	})
+
+	t.Run("check request", func(t *testing.T) {
+		first, second := &OperatorMock{}, &OperatorMock{}
+		// Operators that don't implement RequestChecker are asked with AllowRequest.
+		first.On("AllowRequest").Return(true).Once()
+		second.On("AllowRequest").Return(false).Once()
+
+		op := NewCompositeOperator(first, second)
+		require.False(t, CheckRequest(op))
+
+		first.On("GetStats").Return(&stats.BackpressureStats{}, nil)
+		second.On("GetStats").Return(&stats.BackpressureStats{}, nil)
+
+		st, err := op.GetStats()
+		require.NoError(t, err)
+		require.Zero(t, st.Throttling.Total)
+
+		first.AssertExpectations(t)
+		second.AssertExpectations(t)
+	})
+
+	t.Run("get stats", func(t *testing.T) {
+		params := &stats.ControlParameters{GOGC: 50, ThrottlingPercentage: 10}
+
+		first, second := &OperatorMock{}, &OperatorMock{}
+		first.On("GetStats").Return(&stats.BackpressureStats{}, nil).Once()
+		second.On("GetStats").Return(&stats.BackpressureStats{ControlParameters: params}, nil).Once()
+
+		op := NewCompositeOperator(first, second)
+
+		st, err := op.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, params, st.ControlParameters)
+
+		// statistics of the operators that haven't failed are kept
+		first.On("GetStats").Return(nil, errors.New("failed")).Once()
+		second.On("GetStats").Return(&stats.BackpressureStats{ControlParameters: params}, nil).Once()
+
+		st, err = op.GetStats()
+		require.ErrorContains(t, err, "operator #0: failed")
+		require.Equal(t, params, st.ControlParameters)
+		require.NotNil(t, st.Throttling)
+	})
+
+	t.Run("quit", func(t *testing.T) {
+		var order []string
+
+		first, second := &OperatorMock{}, &OperatorMock{}
+		first.On("Quit").Run(func(mock.Arguments) { order = append(order, "first") })
+		second.On("Quit").Run(func(mock.Arguments) { order = append(order, "second") })
+
+		NewCompositeOperator(first, second).Quit()
+
+		require.Equal(t, []string{"second", "first"}, order)
+	})
+}
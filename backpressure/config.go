@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"errors"
+)
+
+// defaultActuatorMax is the default upper bound of the applied value.
+const defaultActuatorMax = 100
+
+// Config - backpressure operator configuration.
+type Config struct {
+	// GOGC - GOGC actuator settings (debug.SetGCPercent).
+	GOGC *ActuatorConfig `json:"gogc"`
+	// Throttling - request throttling actuator settings.
+	Throttling *ActuatorConfig `json:"throttling"`
+	// Audit - optional sampling settings of the control decisions audit (see WithAuditSink).
+	Audit *AuditConfig `json:"audit"`
+}
+
+// ActuatorConfig - settings of the actuator applying a single control parameter.
+type ActuatorConfig struct {
+	// Disabled - turns actuator off, so the control parameter is never applied.
+	Disabled bool `json:"disabled"`
+	// Min - lower bound of the applied value.
+	Min int `json:"min"`
+	// Max - upper bound of the applied value. Zero means default value (100).
+	Max int `json:"max"`
+	// MaxStep - maximal change of the applied value per single update (the rate of change is limited
+	// with MaxStep per controller period). Zero means unlimited.
+	MaxStep int `json:"max_step"`
+	// Deadband - changes of the applied value smaller than Deadband are ignored,
+	// so the value is not rewritten on every tiny change. Returning to the default value
+	// (GOGC = 100, no throttling) is never ignored. Zero means disabled.
+	Deadband int `json:"deadband"`
+}
+
+// Prepare - config validator.
+func (c *ActuatorConfig) Prepare() error {
+	if c.Max == 0 {
+		c.Max = defaultActuatorMax
+	}
+
+	if c.Min < 0 || c.Max > defaultActuatorMax || c.Min > c.Max {
+		return errors.New("invalid Min or Max values (must satisfy 0 <= Min <= Max <= 100)")
+	}
+
+	if c.MaxStep < 0 {
+		return errors.New("invalid MaxStep value (must be non-negative)")
+	}
+
+	if c.Deadband < 0 {
+		return errors.New("invalid Deadband value (must be non-negative)")
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+// ErrRequestCancelled is the cause of the contexts cancelled by InFlightRegistry.
+// Use context.Cause to distinguish such cancellations from the other ones.
+var ErrRequestCancelled = errors.New("request cancelled due to critical memory pressure")
+
+// CancellationPolicy - the order in which in-flight requests are cancelled.
+type CancellationPolicy string
+
+const (
+	// CancellationPolicyPriority cancels requests with the lowest priority first (the oldest ones among equals).
+	CancellationPolicyPriority CancellationPolicy = "priority"
+	// CancellationPolicyOldest cancels the oldest requests first.
+	CancellationPolicyOldest CancellationPolicy = "oldest"
+	// CancellationPolicyLargest cancels the largest requests first.
+	CancellationPolicyLargest CancellationPolicy = "largest"
+)
+
+// defaultCancellationBatchSize is the default number of requests cancelled per controller update.
+const defaultCancellationBatchSize = 1
+
+// InFlightConfig - in-flight requests cancellation settings.
+type InFlightConfig struct {
+	// CriticalZone - memory budget utilization threshold that makes registry cancel in-flight requests.
+	// Possible values are in range (0; 100].
+	CriticalZone uint32 `json:"critical_zone"`
+	// Policy - cancellation order: "priority", "oldest" or "largest". Empty value means "priority".
+	Policy CancellationPolicy `json:"policy"`
+	// BatchSize - maximal number of requests cancelled per controller update. Zero means default value (1).
+	BatchSize int `json:"batch_size"`
+}
+
+// Prepare - config validator.
+func (c *InFlightConfig) Prepare() error {
+	if c.CriticalZone == 0 || c.CriticalZone > 100 {
+		return errors.New("invalid CriticalZone value (must belong to (0; 100])")
+	}
+
+	if c.Policy == "" {
+		c.Policy = CancellationPolicyPriority
+	}
+
+	switch c.Policy {
+	case CancellationPolicyPriority, CancellationPolicyOldest, CancellationPolicyLargest:
+	default:
+		return fmt.Errorf("invalid Policy value '%s'", c.Policy)
+	}
+
+	if c.BatchSize < 0 {
+		return errors.New("invalid BatchSize value (must be non-negative)")
+	}
+
+	if c.BatchSize == 0 {
+		c.BatchSize = defaultCancellationBatchSize
+	}
+
+	return nil
+}
+
+// InFlightRegistry is an Operator that keeps track of the requests being served and cancels
+// some of them when memory budget utilization reaches the critical zone: at that point
+// rejecting new requests is not enough, since the already admitted ones keep allocating.
+// In the shadow mode (see WithShadowMode) requests are never cancelled, the registry only counts
+// the ones it would have cancelled.
+// The registry is supposed to be combined with the default operator:
+//
+//	registry, err := backpressure.NewInFlightRegistry(logger, cfg)
+//	operator := backpressure.NewCompositeOperator(backpressure.NewOperator(logger), registry)
+type InFlightRegistry interface {
+	Operator
+	// Track registers the request. The request must be served within the returned InFlightRequest context,
+	// and InFlightRequest.Done must be called when the request is served.
+	Track(ctx context.Context, priority int, size uint64) *InFlightRequest
+}
+
+// InFlightRequest - the request registered in InFlightRegistry.
+type InFlightRequest struct {
+	ctx      context.Context //nolint:containedctx // Context is the very thing being tracked.
+	cancel   context.CancelCauseFunc
+	registry *inFlightRegistryImpl
+	// seq is the sequence number of the request reflecting the order of registration.
+	seq      uint64
+	priority int
+	size     atomic.Uint64
+	// cancelled is set when the request has been chosen for cancellation.
+	cancelled bool
+}
+
+// Context returns the context of the request that is cancelled by registry
+// with ErrRequestCancelled cause.
+func (r *InFlightRequest) Context() context.Context { return r.ctx }
+
+// AddSize increases the estimated request size (useful for the streams).
+func (r *InFlightRequest) AddSize(size uint64) { r.size.Add(size) }
+
+// Done unregisters the request.
+func (r *InFlightRequest) Done() {
+	r.registry.remove(r)
+	r.cancel(nil)
+}
+
+var _ InFlightRegistry = (*inFlightRegistryImpl)(nil)
+
+// inFlightRegistryImpl is the implementation of the InFlightRegistry interface.
+type inFlightRegistryImpl struct {
+	cfg *InFlightConfig
+	// requests are the requests being served.
+	requests map[*InFlightRequest]struct{}
+	// cancelled is the total number of cancelled requests.
+	cancelled uint64
+	// shadowCancelled is the total number of requests that would have been cancelled in the shadow mode.
+	shadowCancelled uint64
+	// shadow is set in the shadow mode.
+	shadow bool
+	// seq is the sequence number of the latest registered request.
+	seq uint64
+	// mutex protects requests, cancelled, shadowCancelled and seq.
+	mutex  sync.Mutex
+	logger logr.Logger
+}
+
+// NewInFlightRegistry constructs a new InFlightRegistry. The only option taken into account is WithShadowMode.
+func NewInFlightRegistry(logger logr.Logger, cfg *InFlightConfig, options ...Option) (InFlightRegistry, error) {
+	if cfg == nil {
+		return nil, errors.New("nil config")
+	}
+
+	if err := cfg.Prepare(); err != nil {
+		return nil, fmt.Errorf("prepare config: %w", err)
+	}
+
+	out := &inFlightRegistryImpl{
+		cfg:      cfg,
+		requests: make(map[*InFlightRequest]struct{}),
+		logger:   logger,
+	}
+
+	for _, op := range options {
+		if _, ok := op.(*shadowModeOption); ok {
+			out.shadow = true
+		}
+	}
+
+	return out, nil
+}
+
+// Track registers the request.
+func (r *inFlightRegistryImpl) Track(ctx context.Context, priority int, size uint64) *InFlightRequest {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	out := &InFlightRequest{
+		ctx:      ctx,
+		cancel:   cancel,
+		registry: r,
+		priority: priority,
+	}
+
+	out.size.Store(size)
+
+	r.mutex.Lock()
+	r.seq++
+	out.seq = r.seq
+	r.requests[out] = struct{}{}
+	r.mutex.Unlock()
+
+	return out
+}
+
+// remove unregisters the request.
+func (r *inFlightRegistryImpl) remove(request *InFlightRequest) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.requests, request)
+}
+
+// SetControlParameters cancels in-flight requests if memory budget utilization is critical.
+func (r *inFlightRegistryImpl) SetControlParameters(value *stats.ControlParameters) error {
+	if value == nil || value.ControllerStats == nil || value.ControllerStats.MemoryBudget == nil {
+		return nil
+	}
+
+	utilization := value.ControllerStats.MemoryBudget.Utilization
+	if utilization*percents < float64(r.cfg.CriticalZone) {
+		return nil
+	}
+
+	victims := r.chooseVictims()
+	if len(victims) == 0 {
+		return nil
+	}
+
+	if r.shadow {
+		r.logger.Info(
+			"in-flight requests would have been cancelled",
+			"count", len(victims),
+			"utilization", utilization,
+			"policy", r.cfg.Policy,
+		)
+
+		return nil
+	}
+
+	for _, victim := range victims {
+		victim.cancel(ErrRequestCancelled)
+	}
+
+	r.logger.Info(
+		"in-flight requests cancelled",
+		"count", len(victims),
+		"utilization", utilization,
+		"policy", r.cfg.Policy,
+	)
+
+	return nil
+}
+
+// chooseVictims picks requests that have to be cancelled according to the policy.
+// In the shadow mode victims are only marked, so that they're counted once.
+func (r *inFlightRegistryImpl) chooseVictims() []*InFlightRequest {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	candidates := make([]*InFlightRequest, 0, len(r.requests))
+
+	for request := range r.requests {
+		if !request.cancelled {
+			candidates = append(candidates, request)
+		}
+	}
+
+	slices.SortFunc(candidates, r.compare)
+
+	victims := candidates[:min(len(candidates), r.cfg.BatchSize)]
+
+	for _, victim := range victims {
+		victim.cancelled = true
+	}
+
+	if r.shadow {
+		r.shadowCancelled += uint64(len(victims))
+	} else {
+		r.cancelled += uint64(len(victims))
+	}
+
+	return victims
+}
+
+// compare orders requests so that the ones that have to be cancelled first come first.
+func (r *inFlightRegistryImpl) compare(a, b *InFlightRequest) int {
+	oldest := cmp.Compare(a.seq, b.seq)
+
+	switch r.cfg.Policy {
+	case CancellationPolicyPriority:
+		return cmp.Or(cmp.Compare(a.priority, b.priority), oldest)
+	case CancellationPolicyLargest:
+		return cmp.Or(cmp.Compare(b.size.Load(), a.size.Load()), oldest)
+	default:
+		return oldest
+	}
+}
+
+// AllowRequest always allows requests, because registry doesn't throttle anything.
+func (r *inFlightRegistryImpl) AllowRequest() bool { return true }
+
+// GetStats returns cancellation statistics.
+func (r *inFlightRegistryImpl) GetStats() (*stats.BackpressureStats, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return &stats.BackpressureStats{
+		Cancellation: &stats.CancellationStats{
+			InFlight:        len(r.requests),
+			Cancelled:       r.cancelled,
+			ShadowCancelled: r.shadowCancelled,
+		},
+	}, nil
+}
+
+// Quit does nothing, since registry has no background activity.
+func (r *inFlightRegistryImpl) Quit() {}
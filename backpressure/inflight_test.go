@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInFlightRegistry(t *testing.T) {
+	makeRegistry := func(t *testing.T, policy CancellationPolicy) InFlightRegistry {
+		t.Helper()
+
+		registry, err := NewInFlightRegistry(testr.New(t), &InFlightConfig{CriticalZone: 90, Policy: policy})
+		require.NoError(t, err)
+
+		return registry
+	}
+
+	isCancelled := func(request *InFlightRequest) bool {
+		return context.Cause(request.Context()) != nil
+	}
+
+	t.Run("priority", func(t *testing.T) {
+		registry := makeRegistry(t, CancellationPolicyPriority)
+
+		high := registry.Track(context.Background(), 10, 0)
+		low := registry.Track(context.Background(), 1, 0)
+		lowYounger := registry.Track(context.Background(), 1, 0)
+
+		// Utilization is below the critical zone.
+		require.NoError(t, registry.SetControlParameters(makeShrinkControlParameters(0.8)))
+		require.False(t, isCancelled(low))
+
+		require.NoError(t, registry.SetControlParameters(makeShrinkControlParameters(0.95)))
+		require.ErrorIs(t, context.Cause(low.Context()), ErrRequestCancelled)
+		require.False(t, isCancelled(lowYounger))
+		require.False(t, isCancelled(high))
+
+		require.NoError(t, registry.SetControlParameters(makeShrinkControlParameters(0.95)))
+		require.ErrorIs(t, context.Cause(lowYounger.Context()), ErrRequestCancelled)
+		require.False(t, isCancelled(high))
+
+		low.Done()
+		lowYounger.Done()
+
+		backpressureStats, err := registry.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, 1, backpressureStats.Cancellation.InFlight)
+		require.Equal(t, uint64(2), backpressureStats.Cancellation.Cancelled)
+
+		high.Done()
+	})
+
+	t.Run("largest", func(t *testing.T) {
+		registry := makeRegistry(t, CancellationPolicyLargest)
+
+		small := registry.Track(context.Background(), 0, 10)
+		large := registry.Track(context.Background(), 0, 5)
+		large.AddSize(100)
+
+		require.NoError(t, registry.SetControlParameters(makeShrinkControlParameters(1)))
+		require.True(t, isCancelled(large))
+		require.False(t, isCancelled(small))
+	})
+
+	t.Run("oldest", func(t *testing.T) {
+		registry := makeRegistry(t, CancellationPolicyOldest)
+
+		oldest := registry.Track(context.Background(), 100, 0)
+		youngest := registry.Track(context.Background(), 0, 100)
+
+		require.NoError(t, registry.SetControlParameters(makeShrinkControlParameters(1)))
+		require.True(t, isCancelled(oldest))
+		require.False(t, isCancelled(youngest))
+	})
+
+	t.Run("shadow mode", func(t *testing.T) {
+		registry, err := NewInFlightRegistry(
+			testr.New(t),
+			&InFlightConfig{CriticalZone: 90, BatchSize: 1},
+			WithShadowMode(),
+		)
+		require.NoError(t, err)
+
+		first := registry.Track(context.Background(), 0, 0)
+		second := registry.Track(context.Background(), 0, 0)
+
+		for range 3 {
+			require.NoError(t, registry.SetControlParameters(makeShrinkControlParameters(1)))
+		}
+
+		require.False(t, isCancelled(first))
+		require.False(t, isCancelled(second))
+
+		// Every request is counted once.
+		backpressureStats, err := registry.GetStats()
+		require.NoError(t, err)
+		require.Zero(t, backpressureStats.Cancellation.Cancelled)
+		require.Equal(t, uint64(2), backpressureStats.Cancellation.ShadowCancelled)
+
+		first.Done()
+		second.Done()
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewInFlightRegistry(testr.New(t), &InFlightConfig{CriticalZone: 90, Policy: "random"})
+		require.Error(t, err)
+
+		_, err = NewInFlightRegistry(testr.New(t), &InFlightConfig{})
+		require.Error(t, err)
+	})
+}
@@ -31,3 +31,21 @@ type Operator interface {
 	// Quit gracefully terminates backpressure subsystem and restores runtime settings.
 	Quit()
 }
+
+// RequestChecker is an optional interface of Operator making admission decisions that are not
+// registered in the throttling statistics. Middleware uses it for the decisions that don't correspond
+// to requests: admission of individual stream messages and repeated attempts of waiting callers.
+type RequestChecker interface {
+	// CheckRequest makes the same decision as AllowRequest, but doesn't register it in the statistics.
+	CheckRequest() bool
+}
+
+// CheckRequest makes admission decision with CheckRequest method if operator implements RequestChecker,
+// otherwise it falls back to AllowRequest.
+func CheckRequest(operator Operator) bool {
+	if checker, ok := operator.(RequestChecker); ok {
+		return checker.CheckRequest()
+	}
+
+	return operator.AllowRequest()
+}
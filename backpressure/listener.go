@@ -0,0 +1,361 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+// ConnectionMode - the way of handling new connections that can't be admitted.
+type ConnectionMode string
+
+const (
+	// ConnectionModePause makes Accept wait until connection can be admitted;
+	// meanwhile new connections stay in the kernel backlog.
+	ConnectionModePause ConnectionMode = "pause"
+	// ConnectionModeReject makes listener close new connections immediately.
+	ConnectionModeReject ConnectionMode = "reject"
+)
+
+// ConnectionLimiterConfig - connection-level admission settings.
+type ConnectionLimiterConfig struct {
+	// DangerZone - memory budget utilization threshold above which new connections are not admitted.
+	// Possible values are in range (0; 100]. Zero means that utilization is not taken into account.
+	DangerZone uint32 `json:"danger_zone"`
+	// MaxConnections - maximal number of open connections. Zero means unlimited.
+	MaxConnections int64 `json:"max_connections"`
+	// Mode - "pause" or "reject". Empty value means "pause".
+	Mode ConnectionMode `json:"mode"`
+}
+
+// Prepare - config validator.
+func (c *ConnectionLimiterConfig) Prepare() error {
+	if c.DangerZone > 100 {
+		return errors.New("invalid DangerZone value (must belong to [0; 100])")
+	}
+
+	if c.MaxConnections < 0 {
+		return errors.New("invalid MaxConnections value (must be non-negative)")
+	}
+
+	if c.Mode == "" {
+		c.Mode = ConnectionModePause
+	}
+
+	if c.Mode != ConnectionModePause && c.Mode != ConnectionModeReject {
+		return fmt.Errorf("invalid Mode value '%s'", c.Mode)
+	}
+
+	return nil
+}
+
+// ConnectionLimiter is an Operator admitting new network connections: under extreme pressure
+// even accepting connections (TLS handshakes, HTTP/2 buffers) costs memory. The limiter is supposed
+// to be combined with the default operator:
+//
+//	limiter, err := backpressure.NewConnectionLimiter(logger, cfg)
+//	operator := backpressure.NewCompositeOperator(backpressure.NewOperator(logger), limiter)
+//	server.Serve(limiter.Wrap(listener))
+type ConnectionLimiter interface {
+	Operator
+	// Wrap returns listener admitting connections according to memory pressure and connections number.
+	// All the listeners wrapped by the same limiter share the connections limit.
+	Wrap(listener net.Listener) net.Listener
+}
+
+var _ ConnectionLimiter = (*connectionLimiterImpl)(nil)
+
+// connectionLimiterImpl is the implementation of the ConnectionLimiter interface.
+type connectionLimiterImpl struct {
+	cfg *ConnectionLimiterConfig
+	// critical is set when utilization exceeds danger zone.
+	critical atomic.Bool
+	// open is the number of open connections.
+	open atomic.Int64
+	// changed is closed (and replaced) every time connections can probably be admitted.
+	changed chan struct{}
+	// waiters is the number of paused Accept calls; nobody is notified if there are none.
+	waiters atomic.Int64
+	// mutex protects changed.
+	mutex sync.Mutex
+	// shadow is true if limiter runs in the shadow (dry-run) mode: all connections are admitted,
+	// would-be rejections and pauses are only counted.
+	shadow bool
+
+	accepted       atomic.Uint64
+	rejected       atomic.Uint64
+	paused         atomic.Uint64
+	pauseDuration  atomic.Int64
+	shadowRejected atomic.Uint64
+	shadowPaused   atomic.Uint64
+	logger         logr.Logger
+}
+
+// NewConnectionLimiter constructs a new ConnectionLimiter.
+func NewConnectionLimiter(logger logr.Logger, cfg *ConnectionLimiterConfig, options ...Option) (ConnectionLimiter, error) {
+	if cfg == nil {
+		return nil, errors.New("nil config")
+	}
+
+	if err := cfg.Prepare(); err != nil {
+		return nil, fmt.Errorf("prepare config: %w", err)
+	}
+
+	out := &connectionLimiterImpl{
+		cfg:     cfg,
+		changed: make(chan struct{}),
+		logger:  logger,
+	}
+
+	for _, op := range options {
+		if _, ok := op.(*shadowModeOption); ok {
+			out.shadow = true
+		}
+	}
+
+	return out, nil
+}
+
+// SetControlParameters updates the memory pressure state.
+func (c *connectionLimiterImpl) SetControlParameters(value *stats.ControlParameters) error {
+	if c.cfg.DangerZone == 0 {
+		return nil
+	}
+
+	if value == nil || value.ControllerStats == nil || value.ControllerStats.MemoryBudget == nil {
+		return nil
+	}
+
+	critical := value.ControllerStats.MemoryBudget.Utilization*percents >= float64(c.cfg.DangerZone)
+
+	if c.critical.Swap(critical) == critical {
+		return nil
+	}
+
+	c.logger.Info("connection admission changed", "admitting", !critical)
+
+	if !critical {
+		c.notify()
+	}
+
+	return nil
+}
+
+// notify wakes up the paused listeners, if any.
+func (c *connectionLimiterImpl) notify() {
+	if c.waiters.Load() == 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// waitChan returns channel that is closed when connections can probably be admitted.
+func (c *connectionLimiterImpl) waitChan() <-chan struct{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.changed
+}
+
+// admissible checks if a new connection can be admitted.
+func (c *connectionLimiterImpl) admissible() bool {
+	if c.critical.Load() {
+		return false
+	}
+
+	return c.cfg.MaxConnections == 0 || c.open.Load() < c.cfg.MaxConnections
+}
+
+// reserve takes a connection slot if a new connection can be admitted.
+func (c *connectionLimiterImpl) reserve() bool {
+	if c.critical.Load() {
+		return false
+	}
+
+	for {
+		open := c.open.Load()
+		if c.cfg.MaxConnections > 0 && open >= c.cfg.MaxConnections {
+			return false
+		}
+
+		if c.open.CompareAndSwap(open, open+1) {
+			return true
+		}
+	}
+}
+
+// Wrap returns listener admitting connections.
+func (c *connectionLimiterImpl) Wrap(listener net.Listener) net.Listener {
+	return &limitedListener{
+		Listener: listener,
+		limiter:  c,
+		done:     make(chan struct{}),
+	}
+}
+
+// AllowRequest always allows requests, because limiter works on the connection level.
+func (c *connectionLimiterImpl) AllowRequest() bool { return true }
+
+// GetStats returns connection admission statistics.
+func (c *connectionLimiterImpl) GetStats() (*stats.BackpressureStats, error) {
+	return &stats.BackpressureStats{
+		Connections: &stats.ConnectionsStats{
+			Open:           c.open.Load(),
+			Accepted:       c.accepted.Load(),
+			Rejected:       c.rejected.Load(),
+			Paused:         c.paused.Load(),
+			PauseDuration:  time.Duration(c.pauseDuration.Load()),
+			ShadowRejected: c.shadowRejected.Load(),
+			ShadowPaused:   c.shadowPaused.Load(),
+		},
+	}, nil
+}
+
+// Quit does nothing, since limiter has no background activity.
+func (c *connectionLimiterImpl) Quit() {}
+
+// limitedListener admits connections with the help of the limiter.
+type limitedListener struct {
+	net.Listener
+	limiter   *connectionLimiterImpl
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept waits for and returns the next admitted connection.
+// The state may change while the wrapped Accept is blocked, so the connection is admitted only after
+// it has been accepted: in the pause mode it's held until a slot is available, in the reject mode it's closed.
+func (l *limitedListener) Accept() (net.Conn, error) {
+	if l.limiter.shadow {
+		return l.acceptShadow()
+	}
+
+	pause := l.limiter.cfg.Mode == ConnectionModePause
+
+	for {
+		if pause {
+			if err := l.wait(l.limiter.admissible); err != nil {
+				return nil, err
+			}
+		}
+
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err //nolint:wrapcheck // Errors of the wrapped listener are passed as is.
+		}
+
+		if !l.limiter.reserve() {
+			if !pause {
+				l.limiter.rejected.Add(1)
+				_ = conn.Close()
+
+				continue
+			}
+
+			if err := l.wait(l.limiter.reserve); err != nil {
+				_ = conn.Close()
+
+				return nil, err
+			}
+		}
+
+		l.limiter.accepted.Add(1)
+
+		return &limitedConn{Conn: conn, limiter: l.limiter}, nil
+	}
+}
+
+// acceptShadow returns the next connection in the shadow mode: connection is always admitted,
+// but the connection that would have been held (or closed) is counted.
+func (l *limitedListener) acceptShadow() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Errors of the wrapped listener are passed as is.
+	}
+
+	if !l.limiter.reserve() {
+		if l.limiter.cfg.Mode == ConnectionModePause {
+			l.limiter.shadowPaused.Add(1)
+		} else {
+			l.limiter.shadowRejected.Add(1)
+		}
+
+		l.limiter.open.Add(1)
+	}
+
+	l.limiter.accepted.Add(1)
+
+	return &limitedConn{Conn: conn, limiter: l.limiter}, nil
+}
+
+// wait blocks until ready returns true or listener is closed.
+func (l *limitedListener) wait(ready func() bool) error {
+	if ready() {
+		return nil
+	}
+
+	// The waiter is registered before checking readiness again, so that the slot released
+	// in the meantime is either seen by ready or followed by notification.
+	l.limiter.waiters.Add(1)
+	defer l.limiter.waiters.Add(-1)
+
+	l.limiter.paused.Add(1)
+
+	start := time.Now()
+	defer func() { l.limiter.pauseDuration.Add(int64(time.Since(start))) }()
+
+	for {
+		changed := l.limiter.waitChan()
+
+		if ready() {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-l.done:
+			return net.ErrClosed
+		}
+	}
+}
+
+// Close closes the listener and interrupts the paused Accept calls.
+func (l *limitedListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+
+	return l.Listener.Close() //nolint:wrapcheck // Errors of the wrapped listener are passed as is.
+}
+
+// limitedConn releases the connection slot when closed.
+type limitedConn struct {
+	net.Conn
+	limiter   *connectionLimiterImpl
+	closeOnce sync.Once
+}
+
+// Close closes the connection and releases the slot.
+func (c *limitedConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.limiter.open.Add(-1)
+		c.limiter.notify()
+	})
+
+	return c.Conn.Close() //nolint:wrapcheck // Errors of the wrapped connection are passed as is.
+}
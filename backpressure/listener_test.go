@@ -0,0 +1,253 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionLimiter(t *testing.T) {
+	listen := func(t *testing.T, cfg *ConnectionLimiterConfig, options ...Option) (ConnectionLimiter, net.Listener) {
+		t.Helper()
+
+		limiter, err := NewConnectionLimiter(testr.New(t), cfg, options...)
+		require.NoError(t, err)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		wrapped := limiter.Wrap(listener)
+		t.Cleanup(func() { _ = wrapped.Close() })
+
+		return limiter, wrapped
+	}
+
+	dial := func(t *testing.T, listener net.Listener) net.Conn {
+		t.Helper()
+
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+
+		return conn
+	}
+
+	acceptAsync := func(listener net.Listener) <-chan net.Conn {
+		out := make(chan net.Conn, 1)
+
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				out <- conn
+			}
+
+			close(out)
+		}()
+
+		return out
+	}
+
+	t.Run("pause", func(t *testing.T) {
+		limiter, listener := listen(t, &ConnectionLimiterConfig{DangerZone: 90})
+
+		require.NoError(t, limiter.SetControlParameters(makeShrinkControlParameters(0.95)))
+
+		accepted := acceptAsync(listener)
+		dial(t, listener)
+
+		select {
+		case <-accepted:
+			t.Fatal("connection must not be accepted under pressure")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		require.NoError(t, limiter.SetControlParameters(makeShrinkControlParameters(0.5)))
+
+		conn := <-accepted
+		require.NotNil(t, conn)
+		require.NoError(t, conn.Close())
+
+		backpressureStats, err := limiter.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), backpressureStats.Connections.Paused)
+		require.Equal(t, uint64(1), backpressureStats.Connections.Accepted)
+		require.Equal(t, int64(0), backpressureStats.Connections.Open)
+		require.Positive(t, backpressureStats.Connections.PauseDuration)
+	})
+
+	t.Run("pressure while accepting", func(t *testing.T) {
+		limiter, listener := listen(t, &ConnectionLimiterConfig{DangerZone: 90})
+
+		// Accept is already blocked in the wrapped listener when the pressure rises.
+		accepted := acceptAsync(listener)
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, limiter.SetControlParameters(makeShrinkControlParameters(0.95)))
+		dial(t, listener)
+
+		select {
+		case <-accepted:
+			t.Fatal("connection must not be admitted under pressure")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		require.NoError(t, limiter.SetControlParameters(makeShrinkControlParameters(0.5)))
+
+		conn := <-accepted
+		require.NotNil(t, conn)
+		require.NoError(t, conn.Close())
+	})
+
+	t.Run("concurrent reservations", func(t *testing.T) {
+		const maxConnections = 10
+
+		limiter, err := NewConnectionLimiter(testr.New(t), &ConnectionLimiterConfig{MaxConnections: maxConnections})
+		require.NoError(t, err)
+
+		impl, ok := limiter.(*connectionLimiterImpl)
+		require.True(t, ok)
+
+		var (
+			reserved atomic.Int64
+			wg       sync.WaitGroup
+		)
+
+		for range 100 {
+			wg.Go(func() {
+				if impl.reserve() {
+					reserved.Add(1)
+				}
+			})
+		}
+
+		wg.Wait()
+
+		require.Equal(t, int64(maxConnections), reserved.Load())
+		require.Equal(t, int64(maxConnections), impl.open.Load())
+	})
+
+	t.Run("connections limit", func(t *testing.T) {
+		limiter, listener := listen(t, &ConnectionLimiterConfig{MaxConnections: 1})
+
+		dial(t, listener)
+		first := <-acceptAsync(listener)
+
+		accepted := acceptAsync(listener)
+		dial(t, listener)
+
+		select {
+		case <-accepted:
+			t.Fatal("connection must not be accepted over the limit")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		require.NoError(t, first.Close())
+
+		second := <-accepted
+		require.NotNil(t, second)
+
+		backpressureStats, err := limiter.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), backpressureStats.Connections.Open)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		limiter, listener := listen(t, &ConnectionLimiterConfig{DangerZone: 90, Mode: ConnectionModeReject})
+
+		require.NoError(t, limiter.SetControlParameters(makeShrinkControlParameters(0.95)))
+
+		accepted := acceptAsync(listener)
+		conn := dial(t, listener)
+
+		// Connection is closed by server.
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		_, err := conn.Read(make([]byte, 1))
+		require.Error(t, err)
+
+		require.Eventually(t, func() bool {
+			backpressureStats, err := limiter.GetStats()
+			require.NoError(t, err)
+
+			return backpressureStats.Connections.Rejected == 1
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, listener.Close())
+		<-accepted
+	})
+
+	t.Run("close interrupts pause", func(t *testing.T) {
+		limiter, listener := listen(t, &ConnectionLimiterConfig{DangerZone: 90})
+
+		require.NoError(t, limiter.SetControlParameters(makeShrinkControlParameters(0.95)))
+
+		errChan := make(chan error, 1)
+
+		go func() {
+			_, err := listener.Accept()
+			errChan <- err
+		}()
+
+		require.NoError(t, listener.Close())
+		require.ErrorIs(t, <-errChan, net.ErrClosed)
+	})
+
+	t.Run("shadow mode", func(t *testing.T) {
+		for _, mode := range []ConnectionMode{ConnectionModePause, ConnectionModeReject} {
+			limiter, listener := listen(t, &ConnectionLimiterConfig{DangerZone: 90, Mode: mode}, WithShadowMode())
+
+			require.NoError(t, limiter.SetControlParameters(makeShrinkControlParameters(0.95)))
+
+			accepted := acceptAsync(listener)
+			dial(t, listener)
+
+			conn := <-accepted
+			require.NotNil(t, conn)
+
+			backpressureStats, err := limiter.GetStats()
+			require.NoError(t, err)
+			require.Equal(t, int64(1), backpressureStats.Connections.Open)
+			require.Equal(t, uint64(1), backpressureStats.Connections.Accepted)
+			require.Zero(t, backpressureStats.Connections.Paused)
+			require.Zero(t, backpressureStats.Connections.Rejected)
+
+			if mode == ConnectionModePause {
+				require.Equal(t, uint64(1), backpressureStats.Connections.ShadowPaused)
+			} else {
+				require.Equal(t, uint64(1), backpressureStats.Connections.ShadowRejected)
+			}
+
+			require.NoError(t, conn.Close())
+		}
+	})
+
+	t.Run("close without waiters", func(t *testing.T) {
+		limiter, listener := listen(t, &ConnectionLimiterConfig{MaxConnections: 10})
+
+		impl, ok := limiter.(*connectionLimiterImpl)
+		require.True(t, ok)
+
+		changed := impl.waitChan()
+
+		dial(t, listener)
+		conn := <-acceptAsync(listener)
+		require.NoError(t, conn.Close())
+
+		// Nobody waits, so nobody is notified.
+		require.Equal(t, changed, impl.waitChan())
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewConnectionLimiter(testr.New(t), &ConnectionLimiterConfig{Mode: "drop"})
+		require.Error(t, err)
+	})
+}
@@ -10,18 +10,28 @@ import (
 	"fmt"
 	"runtime/debug"
 	"sync/atomic"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/newcloudtechnologies/memlimiter/stats"
 )
 
-var _ Operator = (*operatorImpl)(nil)
+var (
+	_ Operator         = (*operatorImpl)(nil)
+	_ RequestChecker   = (*operatorImpl)(nil)
+	_ PressureReporter = (*operatorImpl)(nil)
+)
 
 // operatorImpl is the implementation of the Operator interface.
 type operatorImpl struct {
 	*throttler
 
-	notificationChan      chan<- *stats.MemLimiterStats
+	notificationChan   chan<- *stats.MemLimiterStats
+	shadow             *shadowTracker
+	gogcActuator       *actuator
+	throttlingActuator *actuator
+	// auditor is not nil if control decisions are audited.
+	auditor               *auditor
 	lastControlParameters atomic.Value
 	initialGOGC           atomic.Int64
 	initialGOGCStored     atomic.Bool
@@ -35,14 +45,36 @@ func NewOperator(logger logr.Logger, options ...Option) Operator {
 		throttler: newThrottler(),
 	}
 
-	//nolint:gocritic
+	var (
+		cfg            = &Config{}
+		auditSink      AuditSink
+		fullAuditSinks []AuditSink
+	)
+
 	for _, op := range options {
 		switch t := op.(type) {
 		case *notificationsOption:
 			out.notificationChan = t.val
+		case *shadowModeOption:
+			out.shadow = newShadowTracker()
+		case *configOption:
+			if t.val != nil {
+				cfg = t.val
+			}
+		case *auditSinkOption:
+			auditSink = t.val
+		case *fullAuditSinkOption:
+			fullAuditSinks = append(fullAuditSinks, t.val)
 		}
 	}
 
+	if auditSink != nil || len(fullAuditSinks) > 0 {
+		out.auditor = newAuditor(auditSink, fullAuditSinks, cfg.Audit)
+	}
+
+	out.gogcActuator = newActuator(cfg.GOGC, DefaultGOGC)
+	out.throttlingActuator = newActuator(cfg.Throttling, NoThrottling)
+
 	return out
 }
 
@@ -50,6 +82,14 @@ func NewOperator(logger logr.Logger, options ...Option) Operator {
 func (b *operatorImpl) GetStats() (*stats.BackpressureStats, error) {
 	result := &stats.BackpressureStats{
 		Throttling: b.getStats(),
+		Actuators: &stats.ActuatorsStats{
+			GOGC:       b.gogcActuator.getStats(),
+			Throttling: b.throttlingActuator.getStats(),
+		},
+	}
+
+	if b.shadow != nil {
+		result.Shadow = b.shadow.getStats()
 	}
 
 	lastControlParameters := b.lastControlParameters.Load()
@@ -65,34 +105,82 @@ func (b *operatorImpl) GetStats() (*stats.BackpressureStats, error) {
 	return result, nil
 }
 
+// Pressure returns the actual memory pressure and the applied throttling percentage without building statistics.
+func (b *operatorImpl) Pressure() Pressure {
+	var out Pressure
+
+	if value, ok := b.lastControlParameters.Load().(*stats.ControlParameters); ok && value != nil {
+		out = pressureFromControlParameters(value)
+	}
+
+	out.ThrottlingPercentage = b.threshold.Load()
+
+	return out
+}
+
 // SetControlParameters sets the control parameters.
 func (b *operatorImpl) SetControlParameters(value *stats.ControlParameters) error {
-	old := b.lastControlParameters.Swap(value)
-	if old != nil {
-		oldControlParameters, ok := old.(*stats.ControlParameters)
-		if !ok {
-			return fmt.Errorf("invalid type cast (%T)", old)
+	b.lastControlParameters.Store(value)
+
+	previousThrottling, previousGOGC := b.throttlingActuator.applied(), b.gogcActuator.applied()
+
+	// Actuators decide which values have to be applied.
+	throttling, throttlingChanged, throttlingRules := b.throttlingActuator.nextWithRules(int(value.ThrottlingPercentage))
+	gogc, gogcChanged, gogcRules := b.gogcActuator.nextWithRules(value.GOGC)
+
+	if b.shadow != nil {
+		b.shadow.observe(value, gogc)
+	}
+
+	// Every decision is audited, including the ones keeping the applied values (sampling is up to auditor).
+	if b.auditor != nil {
+		var gogcRule, throttlingRule stats.ControlRule
+		if value.Explanation != nil {
+			gogcRule, throttlingRule = value.Explanation.GOGCRule, value.Explanation.ThrottlingRule
 		}
 
-		// If control parameters didn't change, we do nothing.
-		if value.EqualsTo(oldControlParameters) {
-			return nil
+		err := b.auditor.emit(
+			time.Now(),
+			value,
+			newAuditParameter(value.GOGC, previousGOGC, gogc, gogcRule, gogcRules),
+			newAuditParameter(int(value.ThrottlingPercentage), previousThrottling, throttling, throttlingRule, throttlingRules),
+			b.shadow != nil,
+		)
+		if err != nil {
+			b.logger.Error(err, "audit control decision")
 		}
 	}
 
+	// If applied values didn't change, we do nothing.
+	if !throttlingChanged && !gogcChanged {
+		return nil
+	}
+
 	// Set the share of the requests that have to be throttled.
-	err := b.setThreshold(value.ThrottlingPercentage)
-	if err != nil {
-		return fmt.Errorf("throttler set threshold: %w", err)
+	if throttlingChanged {
+		//nolint:gosec // Actuator keeps value within [0; 100].
+		err := b.setThreshold(uint32(throttling))
+		if err != nil {
+			return fmt.Errorf("throttler set threshold: %w", err)
+		}
 	}
 
-	// Tune GC pace.
-	oldGOGC := debug.SetGCPercent(value.GOGC)
-	if b.initialGOGCStored.CompareAndSwap(false, true) {
-		b.initialGOGC.Store(int64(oldGOGC))
+	// Tune GC pace (unless operator runs in the shadow mode).
+	if gogcChanged && b.shadow == nil {
+		oldGOGC := debug.SetGCPercent(gogc)
+		if b.initialGOGCStored.CompareAndSwap(false, true) {
+			b.initialGOGC.Store(int64(oldGOGC))
+		}
 	}
 
-	b.logger.Info("control parameters changed", value.ToKeysAndValues()...)
+	keysAndValues := append(
+		value.ToKeysAndValues(),
+		"applied_gogc", gogc,
+		"applied_throttling_percentage", throttling,
+		"shadow", b.shadow != nil,
+	)
+
+	b.logger.Info("control parameters changed", keysAndValues...)
 
 	// Notify client about statistics change.
 	if b.notificationChan != nil {
@@ -54,3 +54,38 @@ func TestOperatorQuitRestoresGOGC(t *testing.T) {
 	prev := debug.SetGCPercent(expectedInitialGOGC)
 	require.Equal(t, expectedInitialGOGC, prev)
 }
+
+func TestOperatorShadowMode(t *testing.T) {
+	const expectedGOGC = 73
+
+	originalBeforeTest := debug.SetGCPercent(expectedGOGC)
+	defer debug.SetGCPercent(originalBeforeTest)
+
+	logger := testr.New(t)
+	op := NewOperator(logger, WithShadowMode())
+
+	for _, zone := range []stats.Zone{stats.ZoneGreen, stats.ZoneThrottling} {
+		err := op.SetControlParameters(&stats.ControlParameters{
+			ControllerStats: &stats.ControllerStats{
+				MemoryBudget: &stats.MemoryBudgetStats{Zone: zone},
+			},
+			GOGC:                 21,
+			ThrottlingPercentage: FullThrottling,
+		})
+		require.NoError(t, err)
+	}
+
+	// Runtime settings are not altered, but throttling decisions are made.
+	require.Equal(t, expectedGOGC, debug.SetGCPercent(expectedGOGC))
+	require.False(t, op.AllowRequest())
+
+	st, err := op.GetStats()
+	require.NoError(t, err)
+	require.Equal(t, 21, st.Shadow.GOGC)
+	require.Contains(t, st.Shadow.ZoneDurations, stats.ZoneGreen.String())
+	require.Contains(t, st.Shadow.ZoneDurations, stats.ZoneThrottling.String())
+
+	op.Quit()
+
+	require.Equal(t, expectedGOGC, debug.SetGCPercent(expectedGOGC))
+}
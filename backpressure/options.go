@@ -26,8 +26,58 @@ func (o notificationsOption) anchor() {}
 // own decisions (taking into account metrics like RSS etc.).
 // This is inspired by the channel that was attached to SetMaxHeap function
 // (see https://github.com/golang/proposal/blob/master/design/48409-soft-memory-limit.md#setmaxheap)
+// Notifications are dropped if client is not ready to read them;
+// memlimiter.Service.Subscribe provides more reliable delivery.
 func WithNotificationsOption(notifications chan<- *stats.MemLimiterStats) Option {
 	return &notificationsOption{
 		val: notifications,
 	}
 }
+
+type shadowModeOption struct{}
+
+func (o shadowModeOption) anchor() {}
+
+// WithShadowMode makes operator run in the shadow (dry-run) mode: control parameters are not applied
+// to Go runtime, but operator records what would have happened (GOGC value, time spent in each zone).
+// Throttling decisions are still made by AllowRequest, so it's up to middleware to ignore them
+// (see middleware.WithShadowMode).
+func WithShadowMode() Option {
+	return &shadowModeOption{}
+}
+
+type configOption struct {
+	val *Config
+}
+
+func (o configOption) anchor() {}
+
+// WithConfig provides operator configuration. The config is expected to be already prepared.
+func WithConfig(cfg *Config) Option {
+	return &configOption{val: cfg}
+}
+
+type auditSinkOption struct {
+	val AuditSink
+}
+
+func (o auditSinkOption) anchor() {}
+
+// WithAuditSink makes operator emit every control decision (including the ones keeping applied GOGC
+// and throttling intact) with explanation into the sink (see NewLogrAuditSink, NewJSONLAuditSink, AuditSinkFunc).
+// Decisions are sampled according to Config.Audit.
+func WithAuditSink(sink AuditSink) Option {
+	return &auditSinkOption{val: sink}
+}
+
+type fullAuditSinkOption struct {
+	val AuditSink
+}
+
+func (o fullAuditSinkOption) anchor() {}
+
+// WithFullAuditSink is similar to WithAuditSink, but the sink receives every control decision
+// regardless of Config.Audit sampling (for example, to keep the complete history of decisions).
+func WithFullAuditSink(sink AuditSink) Option {
+	return &fullAuditSinkOption{val: sink}
+}
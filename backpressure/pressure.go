@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+// Pressure - the actual memory pressure and the applied throttling.
+type Pressure struct {
+	// Zone - memory budget utilization zone.
+	Zone stats.Zone
+	// Utilization - memory budget utilization ratio.
+	Utilization float64
+	// ThrottlingPercentage - applied throttling percentage (it may differ from the one requested
+	// by controller because of the actuator settings).
+	ThrottlingPercentage uint32
+}
+
+// PressureReporter is an optional interface of Operator reporting the actual memory pressure
+// cheaply enough to be called for every rejected request.
+type PressureReporter interface {
+	// Pressure returns the actual memory pressure.
+	Pressure() Pressure
+}
+
+// GetPressure returns the actual memory pressure with Pressure method if operator implements PressureReporter,
+// otherwise it falls back to GetStats (zero value is returned if statistics are not available).
+func GetPressure(operator Operator) Pressure {
+	if reporter, ok := operator.(PressureReporter); ok {
+		return reporter.Pressure()
+	}
+
+	backpressureStats, err := operator.GetStats()
+	if err != nil {
+		return Pressure{}
+	}
+
+	return pressureFromStats(backpressureStats)
+}
+
+// pressureFromStats extracts memory pressure from the statistics.
+func pressureFromStats(backpressureStats *stats.BackpressureStats) Pressure {
+	var out Pressure
+
+	if backpressureStats == nil {
+		return out
+	}
+
+	if value := backpressureStats.ControlParameters; value != nil {
+		out = pressureFromControlParameters(value)
+	}
+
+	if actuators := backpressureStats.Actuators; actuators != nil && actuators.Throttling != nil {
+		//nolint:gosec // Actuator keeps value within [0; 100].
+		out.ThrottlingPercentage = uint32(actuators.Throttling.Value)
+	}
+
+	return out
+}
+
+// pressureFromControlParameters extracts memory pressure from the control parameters
+// (throttling percentage is the requested one).
+func pressureFromControlParameters(value *stats.ControlParameters) Pressure {
+	out := Pressure{ThrottlingPercentage: value.ThrottlingPercentage}
+
+	if value.ControllerStats != nil && value.ControllerStats.MemoryBudget != nil {
+		out.Zone = value.ControllerStats.MemoryBudget.Zone
+		out.Utilization = value.ControllerStats.MemoryBudget.Utilization
+	}
+
+	return out
+}
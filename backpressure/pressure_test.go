@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPressure(t *testing.T) {
+	cfg := &Config{Throttling: &ActuatorConfig{Max: 10}}
+	require.NoError(t, cfg.Throttling.Prepare())
+
+	op := NewOperator(testr.New(t), WithShadowMode(), WithConfig(cfg))
+	require.Equal(t, Pressure{}, GetPressure(op))
+
+	params := &stats.ControlParameters{
+		ControllerStats: &stats.ControllerStats{
+			MemoryBudget: &stats.MemoryBudgetStats{Zone: stats.ZoneThrottling, Utilization: 0.95},
+		},
+		GOGC:                 DefaultGOGC,
+		ThrottlingPercentage: 50,
+	}
+	require.NoError(t, op.SetControlParameters(params))
+
+	// Throttling percentage is the applied one rather than the requested one.
+	expected := Pressure{Zone: stats.ZoneThrottling, Utilization: 0.95, ThrottlingPercentage: 10}
+	require.Equal(t, expected, GetPressure(op))
+
+	// Composite takes the pressure from the child operator.
+	registry := NewShrinkRegistry(testr.New(t))
+	defer registry.Quit()
+
+	require.Equal(t, expected, GetPressure(NewCompositeOperator(registry, op)))
+
+	// Operators that don't report pressure are asked for statistics.
+	mock := &OperatorMock{}
+	mock.On("GetStats").Return(&stats.BackpressureStats{
+		ControlParameters: params,
+		Actuators:         &stats.ActuatorsStats{Throttling: &stats.ActuatorStats{Value: 20}},
+	}, nil)
+
+	require.Equal(t,
+		Pressure{Zone: stats.ZoneThrottling, Utilization: 0.95, ThrottlingPercentage: 20},
+		GetPressure(mock),
+	)
+}
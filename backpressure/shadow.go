@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+// shadowTracker records what would have happened if the operator had been applying control parameters.
+// It is safe for concurrent use.
+type shadowTracker struct {
+	// zoneDurations is the time spent in each zone.
+	zoneDurations map[stats.Zone]time.Duration
+	// lastZone is the latest known zone.
+	lastZone stats.Zone
+	// lastUpdate is the moment of the latest zone observation (zero if zone is unknown yet).
+	lastUpdate time.Time
+	// gogc is the GOGC value that would have been applied.
+	gogc int
+	// mutex protects the state.
+	mutex sync.Mutex
+}
+
+// newShadowTracker creates a new shadowTracker.
+func newShadowTracker() *shadowTracker {
+	return &shadowTracker{
+		zoneDurations: make(map[stats.Zone]time.Duration),
+		gogc:          DefaultGOGC,
+	}
+}
+
+// observe registers control parameters issued by controller and GOGC value that would have been applied.
+func (t *shadowTracker) observe(value *stats.ControlParameters, gogc int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.gogc = gogc
+
+	if value.ControllerStats == nil || value.ControllerStats.MemoryBudget == nil {
+		return
+	}
+
+	now := time.Now()
+
+	if !t.lastUpdate.IsZero() {
+		t.zoneDurations[t.lastZone] += now.Sub(t.lastUpdate)
+	}
+
+	t.lastZone = value.ControllerStats.MemoryBudget.Zone
+	t.lastUpdate = now
+}
+
+// getStats returns shadow mode statistics.
+func (t *shadowTracker) getStats() *stats.ShadowStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	durations := maps.Clone(t.zoneDurations)
+
+	// Take into account the time spent in the actual zone.
+	if !t.lastUpdate.IsZero() {
+		durations[t.lastZone] += time.Since(t.lastUpdate)
+	}
+
+	out := &stats.ShadowStats{
+		ZoneDurations: make(map[string]time.Duration, len(durations)),
+		GOGC:          t.gogc,
+	}
+
+	for zone, duration := range durations {
+		out.ZoneDurations[zone.String()] = duration
+	}
+
+	return out
+}
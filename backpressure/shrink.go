@@ -0,0 +1,347 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/newcloudtechnologies/memlimiter/utils/breaker"
+)
+
+const (
+	// defaultShrinkTimeout is the default time limit for a single Shrink call.
+	defaultShrinkTimeout = time.Second
+	// defaultShrinkCooldown is the default minimal interval between two consecutive Shrink calls.
+	defaultShrinkCooldown = 10 * time.Second
+	// percents is a constant for converting utilization ratio to percents.
+	percents = 100
+)
+
+// Shrinkable is implemented by in-process components (caches, memory pools etc.)
+// that are able to release memory on demand.
+type Shrinkable interface {
+	// Shrink asks component to release memory.
+	// Level is the actual memory budget utilization (for example, 1.0 means 100%),
+	// targetBytes is the amount of memory that is desired to be released.
+	// Implementation must return the amount of memory actually released and respect context deadline.
+	Shrink(ctx context.Context, level float64, targetBytes uint64) (uint64, error)
+}
+
+// ShrinkableConfig - settings of a component releasing memory on demand.
+type ShrinkableConfig struct {
+	// Name - unique component name used in statistics.
+	Name string
+	// Priority - components with lower values are asked to shrink first.
+	Priority int
+	// DangerZone - memory budget utilization threshold that makes the component shrink.
+	// Possible values are in range (0; 100].
+	DangerZone uint32
+	// Timeout - time limit for a single Shrink call. Zero means default value (1s).
+	Timeout time.Duration
+	// Cooldown - minimal interval between two consecutive Shrink calls. Zero means default value (10s).
+	Cooldown time.Duration
+}
+
+// Prepare - config validator.
+func (c *ShrinkableConfig) Prepare() error {
+	if c.Name == "" {
+		return errors.New("empty Name")
+	}
+
+	if c.DangerZone == 0 || c.DangerZone > 100 {
+		return errors.New("invalid DangerZone value (must belong to (0; 100])")
+	}
+
+	if c.Timeout < 0 || c.Cooldown < 0 {
+		return errors.New("negative Timeout or Cooldown")
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = defaultShrinkTimeout
+	}
+
+	if c.Cooldown == 0 {
+		c.Cooldown = defaultShrinkCooldown
+	}
+
+	return nil
+}
+
+// ShrinkRegistry is an Operator that asks registered components to release memory
+// when memory budget utilization rises. Components are called in the order of their priority,
+// every component only when the utilization exceeds its own danger zone.
+// The registry is supposed to be combined with the default operator:
+//
+//	registry := backpressure.NewShrinkRegistry(logger)
+//	operator := backpressure.NewCompositeOperator(backpressure.NewOperator(logger), registry)
+type ShrinkRegistry interface {
+	Operator
+	// Register adds component to the registry.
+	Register(cfg *ShrinkableConfig, component Shrinkable) error
+}
+
+var _ ShrinkRegistry = (*shrinkRegistryImpl)(nil)
+
+// shrinkRegistryImpl is the implementation of the ShrinkRegistry interface.
+type shrinkRegistryImpl struct {
+	// entries are the registered components sorted by priority.
+	entries []*shrinkEntry
+	// mutex protects entries.
+	mutex sync.RWMutex
+	// updates passes the latest control parameters to the background worker.
+	updates chan *stats.ControlParameters
+	logger  logr.Logger
+	breaker *breaker.Breaker
+}
+
+// shrinkEntry is a registered component.
+type shrinkEntry struct {
+	component Shrinkable
+	cfg       *ShrinkableConfig
+	// busy is set while Shrink call is in progress.
+	busy atomic.Bool
+	// mutex protects the statistics.
+	mutex sync.Mutex
+	stats stats.ShrinkableStats
+}
+
+// NewShrinkRegistry constructs a new ShrinkRegistry.
+func NewShrinkRegistry(logger logr.Logger) ShrinkRegistry {
+	out := &shrinkRegistryImpl{
+		updates: make(chan *stats.ControlParameters, 1),
+		logger:  logger,
+		breaker: breaker.NewBreakerWithInitValue(1),
+	}
+
+	go out.loop()
+
+	return out
+}
+
+// Register adds component to the registry.
+func (r *shrinkRegistryImpl) Register(cfg *ShrinkableConfig, component Shrinkable) error {
+	if component == nil {
+		return errors.New("nil component")
+	}
+
+	if cfg == nil {
+		return errors.New("nil config")
+	}
+
+	if err := cfg.Prepare(); err != nil {
+		return fmt.Errorf("prepare config: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, entry := range r.entries {
+		if entry.cfg.Name == cfg.Name {
+			return fmt.Errorf("component '%s' is already registered", cfg.Name)
+		}
+	}
+
+	r.entries = append(r.entries, &shrinkEntry{component: component, cfg: cfg})
+
+	// Stable sort preserves the order of registration for components with equal priority.
+	slices.SortStableFunc(r.entries, func(a, b *shrinkEntry) int {
+		return cmp.Compare(a.cfg.Priority, b.cfg.Priority)
+	})
+
+	return nil
+}
+
+// SetControlParameters passes the control parameters to the background worker.
+// It never blocks: if the worker is busy, the stale parameters are replaced with the actual ones.
+func (r *shrinkRegistryImpl) SetControlParameters(value *stats.ControlParameters) error {
+	for {
+		select {
+		case r.updates <- value:
+			return nil
+		default:
+		}
+
+		select {
+		case <-r.updates:
+		default:
+		}
+	}
+}
+
+// AllowRequest always allows requests, because registry doesn't throttle anything.
+func (r *shrinkRegistryImpl) AllowRequest() bool { return true }
+
+// GetStats returns statistics of the registered components.
+func (r *shrinkRegistryImpl) GetStats() (*stats.BackpressureStats, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	out := &stats.ShrinkingStats{
+		Components: make(map[string]*stats.ShrinkableStats, len(r.entries)),
+	}
+
+	for _, entry := range r.entries {
+		entry.mutex.Lock()
+		entryStats := entry.stats
+		entry.mutex.Unlock()
+
+		out.Components[entry.cfg.Name] = &entryStats
+	}
+
+	return &stats.BackpressureStats{Shrinking: out}, nil
+}
+
+// Quit terminates the background worker.
+func (r *shrinkRegistryImpl) Quit() {
+	r.breaker.ShutdownAndWait()
+}
+
+// loop is the main loop of the background worker.
+func (r *shrinkRegistryImpl) loop() {
+	defer r.breaker.Dec()
+
+	for {
+		select {
+		case value := <-r.updates:
+			r.shrink(value)
+		case <-r.breaker.Done():
+			return
+		}
+	}
+}
+
+// shrink calls components according to the actual memory budget utilization.
+func (r *shrinkRegistryImpl) shrink(value *stats.ControlParameters) {
+	if value == nil || value.ControllerStats == nil || value.ControllerStats.MemoryBudget == nil {
+		return
+	}
+
+	budget := value.ControllerStats.MemoryBudget
+
+	r.mutex.RLock()
+	entries := slices.Clone(r.entries)
+	r.mutex.RUnlock()
+
+	var freed uint64
+
+	for _, entry := range entries {
+		if budget.Utilization*percents < float64(entry.cfg.DangerZone) {
+			continue
+		}
+
+		// Memory released by the components with higher priority counts too.
+		target := excessBytes(budget, entry.cfg.DangerZone)
+		if target <= freed {
+			continue
+		}
+
+		freed += r.shrinkEntry(entry, budget.Utilization, target-freed)
+	}
+}
+
+// shrinkEntry calls a single component and waits for the result no longer than timeout.
+func (r *shrinkRegistryImpl) shrinkEntry(entry *shrinkEntry, level float64, target uint64) uint64 {
+	// Skip components that are still busy with the previous call (probably ignoring the deadline).
+	if !entry.busy.CompareAndSwap(false, true) {
+		return 0
+	}
+
+	entry.mutex.Lock()
+
+	if time.Since(entry.stats.LastCall) < entry.cfg.Cooldown {
+		entry.mutex.Unlock()
+		entry.busy.Store(false)
+
+		return 0
+	}
+
+	entry.stats.LastCall = time.Now()
+	entry.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.breaker, entry.cfg.Timeout)
+	defer cancel()
+
+	type result struct {
+		freed uint64
+		err   error
+		// timedOut is set if the call has finished after the deadline.
+		timedOut bool
+	}
+
+	resultChan := make(chan result, 1)
+
+	go func() {
+		defer entry.busy.Store(false)
+
+		freed, err := entry.component.Shrink(ctx, level, target)
+
+		// The error of the call that hasn't fit into the timeout is most likely caused by the deadline,
+		// so it's counted as a timeout, not as a failure.
+		timedOut := ctx.Err() != nil
+		entry.register(freed, err, timedOut)
+
+		resultChan <- result{freed: freed, err: err, timedOut: timedOut}
+	}()
+
+	select {
+	case res := <-resultChan:
+		switch {
+		case res.timedOut:
+			r.registerTimeout(entry)
+		case res.err != nil:
+			r.logger.Error(res.err, "shrink component", "name", entry.cfg.Name)
+		}
+
+		return res.freed
+	case <-ctx.Done():
+		r.registerTimeout(entry)
+
+		return 0
+	}
+}
+
+// registerTimeout updates statistics of the component that hasn't fit into the timeout.
+func (r *shrinkRegistryImpl) registerTimeout(entry *shrinkEntry) {
+	entry.mutex.Lock()
+	entry.stats.Timeouts++
+	entry.mutex.Unlock()
+
+	r.logger.Info("shrink component timed out", "name", entry.cfg.Name, "timeout", entry.cfg.Timeout)
+}
+
+// register updates component statistics.
+func (e *shrinkEntry) register(freed uint64, err error, timedOut bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.stats.Calls++
+	e.stats.FreedBytes += freed
+	e.stats.LastFreedBytes = freed
+
+	if err != nil && !timedOut {
+		e.stats.Failures++
+	}
+}
+
+// excessBytes estimates the amount of memory that must be released to return utilization below the threshold.
+func excessBytes(budget *stats.MemoryBudgetStats, dangerZone uint32) uint64 {
+	excess := budget.Utilization - float64(dangerZone)/percents
+	if excess <= 0 {
+		return 0
+	}
+
+	return uint64(excess * float64(budget.GoAllocLimit))
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package backpressure
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+)
+
+type shrinkableStub struct {
+	mutex   sync.Mutex
+	targets []uint64
+	freed   uint64
+	block   bool
+}
+
+func (s *shrinkableStub) Shrink(ctx context.Context, _ float64, targetBytes uint64) (uint64, error) {
+	s.mutex.Lock()
+	s.targets = append(s.targets, targetBytes)
+	s.mutex.Unlock()
+
+	if s.block {
+		<-ctx.Done()
+
+		return 0, ctx.Err()
+	}
+
+	return s.freed, nil
+}
+
+func (s *shrinkableStub) getTargets() []uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]uint64{}, s.targets...)
+}
+
+func makeShrinkControlParameters(utilization float64) *stats.ControlParameters {
+	return &stats.ControlParameters{
+		ControllerStats: &stats.ControllerStats{
+			MemoryBudget: &stats.MemoryBudgetStats{
+				GoAllocLimit: 1000,
+				Utilization:  utilization,
+			},
+		},
+	}
+}
+
+func TestShrinkRegistry(t *testing.T) {
+	t.Run("register", func(t *testing.T) {
+		registry := NewShrinkRegistry(testr.New(t))
+		defer registry.Quit()
+
+		require.Error(t, registry.Register(&ShrinkableConfig{Name: "cache"}, &shrinkableStub{}))
+		require.Error(t, registry.Register(&ShrinkableConfig{Name: "cache", DangerZone: 50}, nil))
+		require.NoError(t, registry.Register(&ShrinkableConfig{Name: "cache", DangerZone: 50}, &shrinkableStub{}))
+		require.Error(t, registry.Register(&ShrinkableConfig{Name: "cache", DangerZone: 60}, &shrinkableStub{}))
+	})
+
+	t.Run("priority order", func(t *testing.T) {
+		registry := NewShrinkRegistry(testr.New(t))
+		defer registry.Quit()
+
+		pool := &shrinkableStub{freed: 300}
+		cache := &shrinkableStub{freed: 100}
+		index := &shrinkableStub{freed: 100}
+
+		require.NoError(t, registry.Register(&ShrinkableConfig{Name: "pool", Priority: 2, DangerZone: 50}, pool))
+		require.NoError(t, registry.Register(&ShrinkableConfig{Name: "cache", Priority: 1, DangerZone: 50}, cache))
+		require.NoError(t, registry.Register(&ShrinkableConfig{Name: "index", Priority: 3, DangerZone: 95}, index))
+
+		// Green zone: nobody is asked.
+		require.NoError(t, registry.SetControlParameters(makeShrinkControlParameters(0.4)))
+
+		// 40% of budget must be released to return to the 50% threshold,
+		// the cache is asked first, then the pool releases the rest.
+		// The index is not asked, because its danger zone is not reached.
+		require.NoError(t, registry.SetControlParameters(makeShrinkControlParameters(0.9)))
+
+		require.Eventually(t, func() bool {
+			st, err := registry.GetStats()
+			require.NoError(t, err)
+
+			return st.Shrinking.Components["pool"].Calls == 1
+		}, time.Second, time.Millisecond)
+
+		require.Equal(t, []uint64{400}, cache.getTargets())
+		require.Equal(t, []uint64{300}, pool.getTargets())
+		require.Empty(t, index.getTargets())
+
+		st, err := registry.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, uint64(100), st.Shrinking.Components["cache"].FreedBytes)
+		require.Equal(t, uint64(300), st.Shrinking.Components["pool"].FreedBytes)
+		require.Zero(t, st.Shrinking.Components["index"].Calls)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		registry := NewShrinkRegistry(testr.New(t))
+		defer registry.Quit()
+
+		cache := &shrinkableStub{block: true}
+
+		require.NoError(t, registry.Register(
+			&ShrinkableConfig{Name: "cache", DangerZone: 50, Timeout: 10 * time.Millisecond},
+			cache,
+		))
+
+		require.NoError(t, registry.SetControlParameters(makeShrinkControlParameters(0.9)))
+
+		require.Eventually(t, func() bool {
+			st, err := registry.GetStats()
+			require.NoError(t, err)
+
+			cacheStats := st.Shrinking.Components["cache"]
+
+			return cacheStats.Timeouts == 1 && cacheStats.Calls == 1
+		}, time.Second, time.Millisecond)
+
+		st, err := registry.GetStats()
+		require.NoError(t, err)
+		require.Zero(t, st.Shrinking.Components["cache"].Failures)
+	})
+
+	t.Run("cooldown", func(t *testing.T) {
+		registry := NewShrinkRegistry(testr.New(t))
+		defer registry.Quit()
+
+		cache := &shrinkableStub{freed: 100}
+
+		cfg := &ShrinkableConfig{Name: "cache", DangerZone: 50}
+		require.NoError(t, registry.Register(cfg, cache))
+		require.Equal(t, defaultShrinkCooldown, cfg.Cooldown)
+
+		for range 3 {
+			require.NoError(t, registry.SetControlParameters(makeShrinkControlParameters(0.9)))
+
+			require.Eventually(t, func() bool {
+				st, err := registry.GetStats()
+				require.NoError(t, err)
+
+				return st.Shrinking.Components["cache"].Calls == 1
+			}, time.Second, time.Millisecond)
+		}
+
+		// Wait until the worker handles the latest parameters.
+		time.Sleep(50 * time.Millisecond)
+		require.Len(t, cache.getTargets(), 1)
+	})
+}
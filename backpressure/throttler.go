@@ -10,11 +10,20 @@ import (
 	"errors"
 	"math/rand/v2"
 	"sync/atomic"
+	"time"
 
 	"github.com/newcloudtechnologies/memlimiter/stats"
 	"github.com/newcloudtechnologies/memlimiter/utils"
 )
 
+// throttlingWindows are the lengths of the rolling windows reported in the throttling statistics.
+//
+//nolint:gochecknoglobals // Read-only list.
+var throttlingWindows = []time.Duration{10 * time.Second, time.Minute, 5 * time.Minute}
+
+// throttlingWindowResolution is the bucket width of the rolling windows.
+const throttlingWindowResolution = time.Second
+
 // throttler is a struct that implements the throttler.
 // It must not be copied after first use because it contains atomic fields.
 // It is safe for concurrent use.
@@ -25,30 +34,49 @@ type throttler struct {
 	requestsPassed utils.Counter[uint64]
 	// requestsThrottled is the number of requests that were throttled.
 	requestsThrottled utils.Counter[uint64]
+	// recentPassed is the number of requests passed within the longest rolling window.
+	recentPassed *utils.RollingCounter
+	// recentThrottled is the number of requests throttled within the longest rolling window.
+	recentThrottled *utils.RollingCounter
 	// threshold is the percentage of requests that should be throttled.
 	// It must be in the range [0; 100].
 	threshold atomic.Uint32
+	// createdAt is the moment of throttler construction; it helps to compute rates
+	// correctly until the rolling windows are full.
+	createdAt time.Time
 }
 
 // newThrottler creates a new throttler.
 func newThrottler() *throttler {
 	requestsTotal := utils.NewUint64Counter(nil)
 
+	longestWindow := throttlingWindows[len(throttlingWindows)-1]
+
 	return &throttler{
 		requestsTotal:     requestsTotal,
 		requestsPassed:    utils.NewUint64Counter(requestsTotal),
 		requestsThrottled: utils.NewUint64Counter(requestsTotal),
+		recentPassed:      utils.NewRollingCounter(longestWindow, throttlingWindowResolution),
+		recentThrottled:   utils.NewRollingCounter(longestWindow, throttlingWindowResolution),
+		createdAt:         time.Now(),
 	}
 }
 
 // AllowRequest checks if the request should be allowed.
 func (t *throttler) AllowRequest() bool {
+	allowed := t.CheckRequest()
+
+	t.register(allowed)
+
+	return allowed
+}
+
+// CheckRequest makes the same decision as AllowRequest, but doesn't update counters.
+func (t *throttler) CheckRequest() bool {
 	threshold := t.threshold.Load()
 
 	// If throttling is disabled, allow any request.
 	if threshold == 0 {
-		t.requestsPassed.Inc(1)
-
 		return true
 	}
 
@@ -60,15 +88,18 @@ func (t *throttler) AllowRequest() bool {
 	//nolint:gosec // Non-cryptographic RNG is intentional for probabilistic throttling decisions.
 	value := rand.Uint32N(FullThrottling)
 
-	allowed := value >= threshold
+	return value >= threshold
+}
 
+// register updates counters according to the decision made about the request.
+func (t *throttler) register(allowed bool) {
 	if allowed {
 		t.requestsPassed.Inc(1)
+		t.recentPassed.Inc(1)
 	} else {
 		t.requestsThrottled.Inc(1)
+		t.recentThrottled.Inc(1)
 	}
-
-	return allowed
 }
 
 // setThreshold sets the threshold for the throttler.
@@ -84,9 +115,39 @@ func (t *throttler) setThreshold(value uint32) error {
 
 // getStats returns the statistics of the throttler.
 func (t *throttler) getStats() *stats.ThrottlingStats {
-	return &stats.ThrottlingStats{
+	out := &stats.ThrottlingStats{
+		Windows:   make([]*stats.ThrottlingWindowStats, 0, len(throttlingWindows)),
 		Total:     t.requestsTotal.Count(),
 		Passed:    t.requestsPassed.Count(),
 		Throttled: t.requestsThrottled.Count(),
 	}
+
+	uptime := time.Since(t.createdAt)
+
+	for _, window := range throttlingWindows {
+		out.Windows = append(out.Windows, t.getWindowStats(window, uptime))
+	}
+
+	return out
+}
+
+// getWindowStats returns the statistics of the throttler over the rolling window.
+func (t *throttler) getWindowStats(window, uptime time.Duration) *stats.ThrottlingWindowStats {
+	out := &stats.ThrottlingWindowStats{
+		Window:    window,
+		Passed:    t.recentPassed.Sum(window),
+		Throttled: t.recentThrottled.Sum(window),
+	}
+
+	// Until the window is full, the rates are computed over the actual lifetime.
+	seconds := min(window, max(uptime, throttlingWindowResolution)).Seconds()
+
+	out.PassedRate = float64(out.Passed) / seconds
+	out.ThrottledRate = float64(out.Throttled) / seconds
+
+	if total := out.Passed + out.Throttled; total > 0 {
+		out.ThrottledShare = float64(out.Throttled) / float64(total)
+	}
+
+	return out
 }
@@ -134,3 +134,54 @@ func BenchmarkThrottler(b *testing.B) {
 		})
 	}
 }
+
+func TestThrottlerWindows(t *testing.T) {
+	const requests = 100
+
+	th := newThrottler()
+
+	err := th.setThreshold(FullThrottling)
+	require.NoError(t, err)
+
+	for range requests {
+		require.False(t, th.AllowRequest())
+	}
+
+	err = th.setThreshold(NoThrottling)
+	require.NoError(t, err)
+
+	for range requests {
+		require.True(t, th.AllowRequest())
+	}
+
+	st := th.getStats()
+	require.Len(t, st.Windows, len(throttlingWindows))
+
+	for i, window := range st.Windows {
+		require.Equal(t, throttlingWindows[i], window.Window)
+		require.Equal(t, uint64(requests), window.Passed)
+		require.Equal(t, uint64(requests), window.Throttled)
+		require.InDelta(t, 0.5, window.ThrottledShare, 0)
+		// The rates are computed over the throttler lifetime, which is at least one second.
+		require.Positive(t, window.PassedRate)
+		require.LessOrEqual(t, window.PassedRate, float64(requests))
+		require.InDelta(t, window.PassedRate, window.ThrottledRate, 0)
+	}
+}
+
+func TestThrottlerCheckRequest(t *testing.T) {
+	th := newThrottler()
+
+	require.True(t, th.CheckRequest())
+
+	err := th.setThreshold(FullThrottling)
+	require.NoError(t, err)
+
+	require.False(t, th.CheckRequest())
+
+	// Decisions made by CheckRequest are not registered.
+	st := th.getStats()
+	require.Zero(t, st.Total)
+	require.Zero(t, st.Windows[0].Passed)
+	require.Zero(t, st.Windows[0].Throttled)
+}
@@ -10,7 +10,9 @@ import (
 	"errors"
 	"math"
 
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
 	"github.com/newcloudtechnologies/memlimiter/controller/nextgc"
+	"github.com/newcloudtechnologies/memlimiter/middleware"
 	"github.com/newcloudtechnologies/memlimiter/utils/config/bytes"
 )
 
@@ -24,6 +26,29 @@ type Config struct {
 	// TODO:
 	//  if new controller implementation appears, put its config here and make switch in Prepare()
 	//  (only one subsection must be not nil).
+
+	// Backpressure - optional settings of the default backpressure operator
+	// (ignored if operator is provided with WithBackpressureOperator).
+	Backpressure *backpressure.Config `json:"backpressure"`
+	// Cancellation - optional in-flight requests cancellation settings: at critical memory pressure
+	// some of the requests being served are cancelled (ignored if operator is provided with WithBackpressureOperator).
+	Cancellation *backpressure.InFlightConfig `json:"cancellation"`
+	// Admission - optional settings of the transport-neutral admission gate.
+	Admission *AdmissionConfig `json:"admission"`
+	// Debug - optional settings of the control history shown by the debug handler (see NewDebugHandler).
+	Debug *DebugConfig `json:"debug"`
+	// StatsHistory - optional settings of the in-process statistics history (see Service.GetStatsHistory).
+	// If not set, no history is kept.
+	StatsHistory *StatsHistoryConfig `json:"stats_history"`
+	// Diagnostics - optional settings of the automatic capture of pprof profiles and statistics snapshot
+	// when memory budget utilization reaches the configured level.
+	Diagnostics *DiagnosticsConfig `json:"diagnostics"`
+	// Middleware - optional middleware configuration.
+	Middleware *middleware.Config `json:"middleware"`
+	// Shadow - enables shadow (dry-run) mode: controller works as usual, but GOGC is not altered
+	// and requests are never rejected; instead MemLimiter records what would have happened.
+	// It's useful for validation of the config against the real traffic before enforcing it.
+	Shadow bool `json:"shadow"`
 }
 
 // Prepare validates config.
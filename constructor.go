@@ -7,11 +7,14 @@
 package memlimiter
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/middleware"
 	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/prepare"
 )
 
 // NewServiceFromConfig - main entrypoint for MemLimiter.
@@ -23,6 +26,10 @@ func NewServiceFromConfig(
 	var (
 		serviceStatsSubscription stats.ServiceStatsSubscription
 		backpressureOperator     backpressure.Operator
+		middlewareOptions        []middleware.Option
+		expvarName               string
+		auditSink                backpressure.AuditSink
+		history                  *controlHistory
 	)
 
 	for _, op := range options {
@@ -31,6 +38,12 @@ func NewServiceFromConfig(
 			serviceStatsSubscription = t.val
 		case *backpressureOperatorOption:
 			backpressureOperator = t.val
+		case *middlewareOptionsOption:
+			middlewareOptions = append(middlewareOptions, t.val...)
+		case *expvarOption:
+			expvarName = t.name
+		case *auditSinkOption:
+			auditSink = t.val
 		}
 	}
 
@@ -40,12 +53,59 @@ func NewServiceFromConfig(
 	}
 
 	if backpressureOperator == nil {
-		backpressureOperator = backpressure.NewOperator(logger)
+		var operatorOptions []backpressure.Option
+
+		if cfg != nil && cfg.Shadow {
+			operatorOptions = append(operatorOptions, backpressure.WithShadowMode())
+		}
+
+		if cfg != nil && cfg.Backpressure != nil {
+			if err := prepare.Prepare(cfg.Backpressure); err != nil {
+				return nil, fmt.Errorf("prepare backpressure config: %w", err)
+			}
+
+			operatorOptions = append(operatorOptions, backpressure.WithConfig(cfg.Backpressure))
+		}
+
+		if auditSink != nil {
+			operatorOptions = append(operatorOptions, backpressure.WithAuditSink(auditSink))
+		}
+
+		// Debug page shows the decisions of the default operator.
+		if cfg != nil {
+			history = newControlHistory(cfg.Debug)
+			operatorOptions = append(operatorOptions, backpressure.WithFullAuditSink(history))
+		}
+
+		backpressureOperator = backpressure.NewOperator(logger, operatorOptions...)
+
+		if cfg != nil && cfg.Cancellation != nil {
+			var registryOptions []backpressure.Option
+			if cfg.Shadow {
+				registryOptions = append(registryOptions, backpressure.WithShadowMode())
+			}
+
+			registry, err := backpressure.NewInFlightRegistry(logger, cfg.Cancellation, registryOptions...)
+			if err != nil {
+				return nil, fmt.Errorf("new in-flight registry: %w", err)
+			}
+
+			backpressureOperator = backpressure.NewCompositeOperator(backpressureOperator, registry)
+			middlewareOptions = append(middlewareOptions, middleware.WithInFlightRegistry(registry))
+		}
 	}
 
 	if cfg == nil {
-		return newServiceStub(serviceStatsSubscription), nil
+		return newServiceStub(serviceStatsSubscription, middlewareOptions...), nil
 	}
 
-	return newServiceImpl(logger, cfg, serviceStatsSubscription, backpressureOperator)
+	return newServiceImpl(
+		logger,
+		cfg,
+		serviceStatsSubscription,
+		backpressureOperator,
+		history,
+		expvarName,
+		middlewareOptions...,
+	)
 }
@@ -7,12 +7,16 @@
 package memlimiter
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/go-logr/logr/testr"
 	"github.com/newcloudtechnologies/memlimiter/stats"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 )
 
 func TestConstructor(t *testing.T) {
@@ -35,14 +39,37 @@ func TestConstructor(t *testing.T) {
 
 		ss, err := service.GetStats()
 		require.NoError(t, err)
-		require.Nil(t, ss)
+		require.NotNil(t, ss.Middleware)
+		require.Zero(t, ss.Controller.MemoryBudget.RSSActual)
 
 		time.Sleep(2 * delay)
 
 		ss, err = service.GetStats()
 		require.NoError(t, err)
-		require.NotNil(t, ss)
+		require.NotZero(t, ss.Controller.MemoryBudget.RSSActual)
+		require.NotNil(t, ss.Middleware)
 
-		require.Nil(t, service.Middleware())
+		mw := service.Middleware()
+		require.NotNil(t, mw)
+
+		resp, err := mw.GRPC().MakeUnaryServerInterceptor()(
+			context.Background(),
+			"request",
+			&grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"},
+			func(_ context.Context, req any) (any, error) { return req, nil },
+		)
+		require.NoError(t, err)
+		require.Equal(t, "request", resp)
+
+		recorder := httptest.NewRecorder()
+		mw.HTTP().MakeHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		ss, err = service.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), ss.Middleware.GRPCServer.Methods["/test.Service/Method"].Requests)
+		require.Equal(t, uint64(1), ss.Middleware.HTTP.Routes["<default>"].Requests)
 	})
 }
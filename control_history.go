@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/utils"
+)
+
+const (
+	// defaultDebugDecisions is the default number of control decisions kept for the debug page.
+	defaultDebugDecisions = 100
+	// defaultDebugSamples is the default number of control samples kept for the debug page chart.
+	defaultDebugSamples = 360
+)
+
+// DebugConfig - settings of the control history shown by the debug handler (see NewDebugHandler).
+type DebugConfig struct {
+	// Decisions - number of the latest control decisions kept. Zero means default value (100).
+	// Decisions are taken from the audit records of the default backpressure operator.
+	Decisions int `json:"decisions"`
+	// Samples - number of the latest controller updates kept for the chart. Zero means default value (360).
+	Samples int `json:"samples"`
+}
+
+// Prepare - config validator.
+func (c *DebugConfig) Prepare() error {
+	if c.Decisions < 0 || c.Samples < 0 {
+		return errors.New("negative Decisions or Samples")
+	}
+
+	if c.Decisions == 0 {
+		c.Decisions = defaultDebugDecisions
+	}
+
+	if c.Samples == 0 {
+		c.Samples = defaultDebugSamples
+	}
+
+	return nil
+}
+
+// ControlSample - applied control parameters and utilization at the moment of controller update.
+type ControlSample struct {
+	// Time - the moment of the update.
+	Time time.Time `json:"time"`
+	// Utilization - memory budget utilization ratio.
+	Utilization float64 `json:"utilization"`
+	// GOGC - applied GOGC value.
+	GOGC int `json:"gogc"`
+	// ThrottlingPercentage - applied percentage of throttled requests.
+	ThrottlingPercentage uint32 `json:"throttling_percentage"`
+}
+
+// controlHistory keeps the latest control decisions changing applied control parameters and samples.
+// It's fed with the audit records of the default backpressure operator. It is safe for concurrent use.
+type controlHistory struct {
+	decisions *utils.Ring[*backpressure.AuditRecord]
+	samples   *utils.Ring[*ControlSample]
+	// started is set after the first record.
+	started bool
+	// mutex protects the state.
+	mutex sync.Mutex
+}
+
+var _ backpressure.AuditSink = (*controlHistory)(nil)
+
+func newControlHistory(cfg *DebugConfig) *controlHistory {
+	decisions, samples := defaultDebugDecisions, defaultDebugSamples
+	if cfg != nil && cfg.Decisions > 0 {
+		decisions = cfg.Decisions
+	}
+
+	if cfg != nil && cfg.Samples > 0 {
+		samples = cfg.Samples
+	}
+
+	return &controlHistory{
+		decisions: utils.NewRing[*backpressure.AuditRecord](decisions),
+		samples:   utils.NewRing[*ControlSample](samples),
+	}
+}
+
+// WriteAuditRecord records controller update; the record is kept as a decision
+// if it's the first one or it changes applied GOGC or throttling.
+func (h *controlHistory) WriteAuditRecord(record *backpressure.AuditRecord) error {
+	if record == nil || record.GOGC == nil || record.Throttling == nil {
+		return nil
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.samples.Push(&ControlSample{
+		Time:        record.Time,
+		Utilization: record.Utilization,
+		GOGC:        record.GOGC.Applied,
+		//nolint:gosec // Actuator keeps value within [0; 100].
+		ThrottlingPercentage: uint32(record.Throttling.Applied),
+	})
+
+	changed := record.GOGC.Applied != record.GOGC.Previous || record.Throttling.Applied != record.Throttling.Previous
+
+	if h.started && !changed {
+		return nil
+	}
+
+	h.started = true
+	h.decisions.Push(record)
+
+	return nil
+}
+
+// get returns the copies of the decisions and samples from the oldest to the newest.
+func (h *controlHistory) get() ([]*backpressure.AuditRecord, []*ControlSample) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.decisions.Values(), h.samples.Values()
+}
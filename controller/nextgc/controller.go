@@ -35,6 +35,9 @@ type controllerImpl struct {
 	// cached values, describing the actual state of the controller:
 	pValue            float64                  // proportional component's output
 	sumValue          float64                  // final output
+	outputSaturated   bool                     // final output has been clamped
+	cgoAllocs         uint64                   // memory consumed beyond the Cgo border
+	budgetExhausted   bool                     // Cgo consumers have exhausted the RSS limit
 	goAllocLimit      uint64                   // memory budget [bytes]
 	utilization       float64                  // memory budget utilization ratio (1.0 = 100%)
 	rss               uint64                   // physical memory actual consumption
@@ -181,6 +184,8 @@ func (c *controllerImpl) updateUtilization(serviceStats stats.ServiceStats) {
 
 	goAllocLimit, budgetOK := c.computeGoAllocLimit(cgoAllocs)
 	c.goAllocLimit = goAllocLimit
+	c.cgoAllocs = cgoAllocs
+	c.budgetExhausted = !budgetOK
 
 	// Memory utilization is defined as the relation of NextGC value to the Go allocation limit.
 	// If NextGC becomes higher than the allocation limit, the GC will never run, because
@@ -237,14 +242,24 @@ func (c *controllerImpl) updateControlValues() error {
 		upperBound = 99 // this otherwise GOGC will turn to zero
 	)
 
-	c.sumValue = memlimiter_utils.ClampFloat64(c.sumValue, lowerBound, upperBound)
+	saturated := memlimiter_utils.ClampFloat64(c.sumValue, lowerBound, upperBound)
+	c.outputSaturated = saturated != c.sumValue
+	c.sumValue = saturated
 
 	return nil
 }
 
 // updateControlParameters updates the controller control parameters.
 func (c *controllerImpl) updateControlParameters() {
-	c.controlParameters = &stats.ControlParameters{}
+	c.controlParameters = &stats.ControlParameters{
+		Explanation: &stats.ControlExplanation{
+			CgoTotal:             c.cgoAllocs,
+			BudgetExhausted:      c.budgetExhausted,
+			OutputSaturated:      c.outputSaturated,
+			DangerZoneGOGC:       c.cfg.DangerZoneGOGC,
+			DangerZoneThrottling: c.cfg.DangerZoneThrottling,
+		},
+	}
 	c.updateControlParameterGOGC()
 	c.updateControlParameterThrottling()
 
@@ -265,6 +280,7 @@ func (c *controllerImpl) updateControlParameterGOGC() {
 	// Control parameters are set to defaults in the "green zone".
 	if uint32(c.utilization*percents) < c.cfg.DangerZoneGOGC {
 		c.controlParameters.GOGC = backpressure.DefaultGOGC
+		c.controlParameters.Explanation.GOGCRule = stats.ControlRuleBelowDangerZone
 
 		return
 	}
@@ -278,8 +294,11 @@ func (c *controllerImpl) updateControlParameterGOGC() {
 		minGOGC = defaultMinGOGC
 	}
 
+	c.controlParameters.Explanation.GOGCRule = stats.ControlRuleOutput
+
 	if gogc < minGOGC {
 		gogc = minGOGC
+		c.controlParameters.Explanation.GOGCRule = stats.ControlRuleMinGOGC
 	}
 
 	c.controlParameters.GOGC = gogc
@@ -290,6 +309,7 @@ func (c *controllerImpl) updateControlParameterThrottling() {
 	// Disable throttling in the "green zone".
 	if uint32(c.utilization*percents) < c.cfg.DangerZoneThrottling {
 		c.controlParameters.ThrottlingPercentage = backpressure.NoThrottling
+		c.controlParameters.Explanation.ThrottlingRule = stats.ControlRuleBelowDangerZone
 
 		return
 	}
@@ -297,6 +317,23 @@ func (c *controllerImpl) updateControlParameterThrottling() {
 	// Control parameters are more conservative in the "red zone".
 	roundedValue := uint32(math.Round(c.sumValue))
 	c.controlParameters.ThrottlingPercentage = roundedValue
+	c.controlParameters.Explanation.ThrottlingRule = stats.ControlRuleOutput
+}
+
+// zone determines memory budget utilization zone.
+func (c *controllerImpl) zone() stats.Zone {
+	utilization := uint32(c.utilization * percents)
+
+	switch {
+	case c.utilization >= 1:
+		return stats.ZoneCritical
+	case utilization >= c.cfg.DangerZoneThrottling:
+		return stats.ZoneThrottling
+	case utilization >= c.cfg.DangerZoneGOGC:
+		return stats.ZoneGOGC
+	default:
+		return stats.ZoneGreen
+	}
 }
 
 // applyControlValue applies the controller control value.
@@ -317,6 +354,7 @@ func (c *controllerImpl) aggregateStats() *stats.ControllerStats {
 			RSSLimit:     c.cfg.RSSLimit.Value,
 			GoAllocLimit: c.goAllocLimit,
 			Utilization:  c.utilization,
+			Zone:         c.zone(),
 		},
 		NextGC: &stats.ControllerNextGCStats{
 			P:      c.pValue,
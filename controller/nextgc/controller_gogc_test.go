@@ -23,7 +23,7 @@ func TestUpdateControlParameterGOGC(t *testing.T) {
 				DangerZoneGOGC: 50,
 				MinGOGC:        10,
 			},
-			controlParameters: &stats.ControlParameters{},
+			controlParameters: &stats.ControlParameters{Explanation: &stats.ControlExplanation{}},
 		}
 
 		c.updateControlParameterGOGC()
@@ -39,12 +39,13 @@ func TestUpdateControlParameterGOGC(t *testing.T) {
 				DangerZoneGOGC: 50,
 				MinGOGC:        0,
 			},
-			controlParameters: &stats.ControlParameters{},
+			controlParameters: &stats.ControlParameters{Explanation: &stats.ControlExplanation{}},
 		}
 
 		c.updateControlParameterGOGC()
 
 		require.Equal(t, defaultMinGOGC, c.controlParameters.GOGC)
+		require.Equal(t, stats.ControlRuleMinGOGC, c.controlParameters.Explanation.GOGCRule)
 	})
 
 	t.Run("green zone keeps default GOGC", func(t *testing.T) {
@@ -55,12 +56,13 @@ func TestUpdateControlParameterGOGC(t *testing.T) {
 				DangerZoneGOGC: 50,
 				MinGOGC:        10,
 			},
-			controlParameters: &stats.ControlParameters{},
+			controlParameters: &stats.ControlParameters{Explanation: &stats.ControlExplanation{}},
 		}
 
 		c.updateControlParameterGOGC()
 
 		require.Equal(t, backpressure.DefaultGOGC, c.controlParameters.GOGC)
+		require.Equal(t, stats.ControlRuleBelowDangerZone, c.controlParameters.Explanation.GOGCRule)
 	})
 
 	t.Run("value above MinGOGC is not clamped", func(t *testing.T) {
@@ -71,11 +73,12 @@ func TestUpdateControlParameterGOGC(t *testing.T) {
 				DangerZoneGOGC: 50,
 				MinGOGC:        10,
 			},
-			controlParameters: &stats.ControlParameters{},
+			controlParameters: &stats.ControlParameters{Explanation: &stats.ControlExplanation{}},
 		}
 
 		c.updateControlParameterGOGC()
 
 		require.Equal(t, 78, c.controlParameters.GOGC)
+		require.Equal(t, stats.ControlRuleOutput, c.controlParameters.Explanation.GOGCRule)
 	})
 }
@@ -107,7 +107,8 @@ func TestController(t *testing.T) {
 	backpressureOperatorMock.On(
 		"SetControlParameters",
 		mock.MatchedBy(func(val *stats.ControlParameters) bool {
-			return val.GOGC == 78 && val.ThrottlingPercentage == 22
+			return val.GOGC == 78 && val.ThrottlingPercentage == 22 &&
+				val.ControllerStats.MemoryBudget.Zone == stats.ZoneThrottling
 		}),
 	).Return(nil).Once().Run(
 		func(_ mock.Arguments) {
@@ -119,7 +120,8 @@ func TestController(t *testing.T) {
 	).On(
 		"SetControlParameters",
 		mock.MatchedBy(func(val *stats.ControlParameters) bool {
-			return val.GOGC == backpressure.DefaultGOGC && val.ThrottlingPercentage == backpressure.NoThrottling
+			return val.GOGC == backpressure.DefaultGOGC && val.ThrottlingPercentage == backpressure.NoThrottling &&
+				val.ControllerStats.MemoryBudget.Zone == stats.ZoneGreen
 		}),
 	).Return(nil).Once().Run(
 		func(_ mock.Arguments) {
@@ -0,0 +1,269 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+// DebugState - MemLimiter state rendered by the debug handler.
+type DebugState struct {
+	// Config - effective MemLimiter configuration (nil for the service stub).
+	Config *Config `json:"config"`
+	// Stats - current MemLimiter statistics.
+	Stats *stats.MemLimiterStats `json:"stats"`
+	// Decisions - audit records of the latest control decisions changing applied GOGC or throttling
+	// from the oldest to the newest.
+	Decisions []*backpressure.AuditRecord `json:"decisions"`
+	// Samples - the latest controller updates from the oldest to the newest.
+	Samples []*ControlSample `json:"samples"`
+	// History - statistics samples within the requested window from the oldest to the newest
+	// (see Service.GetStatsHistory).
+	History []*StatsSample `json:"history,omitempty"`
+}
+
+// debugStateProvider is implemented by the services supporting the debug handler.
+type debugStateProvider interface {
+	// debugState returns the state including statistics history within the window (zero means no history).
+	debugState(history time.Duration) (*DebugState, error)
+}
+
+// NewDebugHandler returns http.Handler rendering effective config, current statistics, the latest control
+// decisions and the chart of utilization, GOGC and throttling over time. The state is served as HTML
+// by default and as JSON if requested with "format=json" query parameter or "Accept: application/json" header.
+// Statistics history is included if requested with "history" query parameter (for example, "history=5m").
+// The handler is supposed to be mounted at /debug/memlimiter.
+func NewDebugHandler(service Service) (http.Handler, error) {
+	provider, ok := service.(debugStateProvider)
+	if !ok {
+		return nil, errors.New("service doesn't support debug handler")
+	}
+
+	return &debugHandler{provider: provider}, nil
+}
+
+// debugHandler is the implementation of the debug handler.
+type debugHandler struct {
+	provider debugStateProvider
+}
+
+func (h *debugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var history time.Duration
+
+	if value := r.URL.Query().Get("history"); value != "" {
+		var err error
+
+		if history, err = time.ParseDuration(value); err != nil || history < 0 {
+			http.Error(w, fmt.Sprintf("invalid history window '%s'", value), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	state, err := h.provider.debugState(history)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("get state: %v", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(state)
+
+		return
+	}
+
+	var buf bytes.Buffer
+
+	if err := debugPageTemplate.Execute(&buf, newDebugPage(state)); err != nil {
+		http.Error(w, fmt.Sprintf("render page: %v", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+const (
+	// debugChartWidth is the width of the chart [px].
+	debugChartWidth = 720
+	// debugChartHeight is the height of the chart [px].
+	debugChartHeight = 200
+)
+
+// debugPage is the view model of the debug page.
+type debugPage struct {
+	Zone                 string
+	Utilization          string
+	GOGC                 int
+	ThrottlingPercentage uint32
+	Width, Height        int
+	UtilizationLine      string
+	UtilizationMax       string
+	GOGCLine             string
+	GOGCMax              int
+	ThrottlingLine       string
+	// Decisions are sorted from the newest to the oldest.
+	Decisions []*backpressure.AuditRecord
+	Config    string
+	Stats     string
+}
+
+func newDebugPage(state *DebugState) *debugPage {
+	out := &debugPage{
+		Zone:      "unknown",
+		Width:     debugChartWidth,
+		Height:    debugChartHeight,
+		Decisions: slices.Clone(state.Decisions),
+		Config:    indentJSON(state.Config),
+		Stats:     indentJSON(state.Stats),
+	}
+
+	slices.Reverse(out.Decisions)
+
+	if state.Stats != nil && state.Stats.Controller != nil && state.Stats.Controller.MemoryBudget != nil {
+		out.Zone = state.Stats.Controller.MemoryBudget.Zone.String()
+		out.Utilization = strconv.FormatFloat(state.Stats.Controller.MemoryBudget.Utilization, 'f', 3, 64)
+	}
+
+	if len(state.Samples) > 0 {
+		last := state.Samples[len(state.Samples)-1]
+		out.GOGC = last.GOGC
+		out.ThrottlingPercentage = last.ThrottlingPercentage
+	}
+
+	utilizationMax, gogcMax := 1.0, 100
+
+	for _, sample := range state.Samples {
+		utilizationMax = max(utilizationMax, sample.Utilization)
+		gogcMax = max(gogcMax, sample.GOGC)
+	}
+
+	out.UtilizationMax = strconv.FormatFloat(utilizationMax, 'f', 2, 64)
+	out.GOGCMax = gogcMax
+	out.UtilizationLine = chartLine(state.Samples, utilizationMax, func(s *ControlSample) float64 { return s.Utilization })
+	out.GOGCLine = chartLine(state.Samples, float64(gogcMax), func(s *ControlSample) float64 { return float64(s.GOGC) })
+	out.ThrottlingLine = chartLine(state.Samples, percents, func(s *ControlSample) float64 {
+		return float64(s.ThrottlingPercentage)
+	})
+
+	return out
+}
+
+// percents is a constant for scaling throttling percentage.
+const percents = 100
+
+// chartLine renders samples as SVG polyline points; samples are spread evenly along the X axis.
+func chartLine(samples []*ControlSample, maxValue float64, value func(*ControlSample) float64) string {
+	if len(samples) == 0 || maxValue <= 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	step := float64(debugChartWidth)
+	if len(samples) > 1 {
+		step = float64(debugChartWidth) / float64(len(samples)-1)
+	}
+
+	for i, sample := range samples {
+		y := float64(debugChartHeight) * (1 - min(max(value(sample)/maxValue, 0), 1))
+
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+
+		sb.WriteString(strconv.FormatFloat(float64(i)*step, 'f', 1, 64))
+		sb.WriteByte(',')
+		sb.WriteString(strconv.FormatFloat(y, 'f', 1, 64))
+	}
+
+	return sb.String()
+}
+
+// indentJSON renders value as indented JSON.
+func indentJSON(value any) string {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("marshal error: %v", err)
+	}
+
+	return string(data)
+}
+
+var debugPageTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="5">
+<title>MemLimiter</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }
+pre { background: #f6f6f6; padding: 1em; overflow: auto; max-height: 30em; }
+svg { border: 1px solid #ccc; background: #fff; }
+.utilization { color: #d62728; } .gogc { color: #1f77b4; } .throttling { color: #ff7f0e; }
+</style>
+</head>
+<body>
+<h1>MemLimiter</h1>
+<p>
+Zone: <b>{{.Zone}}</b>,
+utilization: <b>{{.Utilization}}</b>,
+GOGC: <b>{{.GOGC}}</b>,
+throttling: <b>{{.ThrottlingPercentage}}%</b>.
+<a href="?format=json">JSON</a>
+</p>
+<h2>History</h2>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}">
+<polyline fill="none" stroke="#d62728" stroke-width="2" points="{{.UtilizationLine}}"/>
+<polyline fill="none" stroke="#1f77b4" stroke-width="2" points="{{.GOGCLine}}"/>
+<polyline fill="none" stroke="#ff7f0e" stroke-width="2" points="{{.ThrottlingLine}}"/>
+</svg>
+<p>
+<span class="utilization">&#9632; utilization [0; {{.UtilizationMax}}]</span>
+<span class="gogc">&#9632; GOGC [0; {{.GOGCMax}}]</span>
+<span class="throttling">&#9632; throttling [0; 100]%</span>
+</p>
+<h2>Control decisions</h2>
+<table>
+<tr><th>Time</th><th>Zone</th><th>Utilization</th><th>GOGC</th><th>Throttling</th><th>Reason</th></tr>
+{{range .Decisions}}<tr>
+<td>{{.Time.Format "2006-01-02 15:04:05.000"}}{{if .Shadow}} (shadow){{end}}</td>
+<td>{{.Zone}}</td>
+<td>{{printf "%.3f" .Utilization}}</td>
+<td>{{.GOGC.Previous}} &rarr; {{.GOGC.Applied}}</td>
+<td>{{.Throttling.Previous}}% &rarr; {{.Throttling.Applied}}%</td>
+<td>{{.Reason}}</td>
+</tr>
+{{else}}<tr><td colspan="6">no decisions yet</td></tr>
+{{end}}</table>
+<h2>Config</h2>
+<pre>{{.Config}}</pre>
+<h2>Statistics</h2>
+<pre>{{.Stats}}</pre>
+</body>
+</html>
+`))
@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+)
+
+type debugStateProviderStub struct {
+	state *DebugState
+}
+
+func (d *debugStateProviderStub) debugState(history time.Duration) (*DebugState, error) {
+	if history == 0 {
+		return d.state, nil
+	}
+
+	out := *d.state
+	out.History = []*StatsSample{{Time: time.Unix(1000, 0), Stats: d.state.Stats}}
+
+	return &out, nil
+}
+
+// newAuditedControlHistory returns control history fed by the shadow operator
+// applying throttling with the step of 10 percents at most.
+func newAuditedControlHistory(t *testing.T, cfg *DebugConfig) (*controlHistory, backpressure.Operator) {
+	t.Helper()
+
+	operatorCfg := &backpressure.Config{Throttling: &backpressure.ActuatorConfig{MaxStep: 10}}
+	require.NoError(t, operatorCfg.Throttling.Prepare())
+
+	h := newControlHistory(cfg)
+	op := backpressure.NewOperator(
+		testr.New(t),
+		backpressure.WithShadowMode(),
+		backpressure.WithConfig(operatorCfg),
+		backpressure.WithFullAuditSink(h),
+	)
+
+	return h, op
+}
+
+func TestControlHistory(t *testing.T) {
+	h, op := newAuditedControlHistory(t, &DebugConfig{Decisions: 2, Samples: 3})
+
+	require.NoError(t, op.SetControlParameters(makeControlParameters(100, 0, stats.ZoneGreen, 0.5)))
+	require.NoError(t, op.SetControlParameters(makeControlParameters(100, 0, stats.ZoneGreen, 0.6)))
+	require.NoError(t, op.SetControlParameters(makeControlParameters(50, 0, stats.ZoneGOGC, 0.8)))
+	require.NoError(t, op.SetControlParameters(makeControlParameters(20, 30, stats.ZoneThrottling, 0.95)))
+
+	decisions, samples := h.get()
+	require.Len(t, samples, 3)
+	require.InDelta(t, 0.6, samples[0].Utilization, 0)
+	// Samples keep the applied values rather than the requested ones.
+	require.Equal(t, uint32(10), samples[2].ThrottlingPercentage)
+
+	require.Len(t, decisions, 2)
+	require.Equal(t, 100, decisions[0].GOGC.Previous)
+	require.Equal(t, 50, decisions[0].GOGC.Applied)
+	require.Equal(t, "utilization 0.800 in gogc zone; GOGC 100 -> 50", decisions[0].Reason)
+	require.Equal(t, stats.ZoneThrottling, decisions[1].Zone)
+	require.Equal(t, 30, decisions[1].Throttling.Requested)
+	require.Equal(t, 10, decisions[1].Throttling.Applied)
+	require.True(t, decisions[1].Shadow)
+}
+
+func TestDebugHandler(t *testing.T) {
+	h, op := newAuditedControlHistory(t, nil)
+
+	require.NoError(t, op.SetControlParameters(makeControlParameters(100, 0, stats.ZoneGreen, 0.5)))
+	require.NoError(t, op.SetControlParameters(makeControlParameters(20, 30, stats.ZoneThrottling, 0.95)))
+
+	decisions, samples := h.get()
+
+	handler := &debugHandler{provider: &debugStateProviderStub{state: &DebugState{
+		Config: &Config{Shadow: true},
+		Stats: &stats.MemLimiterStats{
+			Controller: &stats.ControllerStats{
+				MemoryBudget: &stats.MemoryBudgetStats{Zone: stats.ZoneThrottling, Utilization: 0.95},
+			},
+		},
+		Decisions: decisions,
+		Samples:   samples,
+	}}}
+
+	t.Run("html", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/memlimiter", nil))
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Contains(t, recorder.Header().Get("Content-Type"), "text/html")
+
+		body := recorder.Body.String()
+		require.Contains(t, body, "Zone: <b>throttling</b>")
+		require.Contains(t, body, "<polyline")
+		require.Contains(t, body, "utilization 0.950 in throttling zone; GOGC 100 -&gt; 20")
+		require.Contains(t, body, "(shadow)")
+		require.Contains(t, body, "&#34;shadow&#34;: true")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/memlimiter?format=json", nil))
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+		var state DebugState
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &state))
+		require.True(t, state.Config.Shadow)
+		require.Len(t, state.Decisions, 2)
+		require.Len(t, state.Samples, 2)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/memlimiter?format=json&history=5m", nil))
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var state DebugState
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &state))
+		require.Len(t, state.History, 1)
+
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/memlimiter?history=forever", nil))
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("service stub", func(t *testing.T) {
+		subscription := stats.NewSubscriptionDefault(testr.New(t), time.Second)
+
+		service, err := NewServiceFromConfig(testr.New(t), nil, WithServiceStatsSubscription(subscription))
+		require.NoError(t, err)
+
+		defer service.Quit()
+
+		stubHandler, err := NewDebugHandler(service)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		stubHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/memlimiter", nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "no decisions yet")
+	})
+}
@@ -0,0 +1,359 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/newcloudtechnologies/memlimiter/utils/breaker"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/bytes"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+)
+
+const (
+	// defaultDiagnosticsCooldown is the default minimal interval between captures.
+	defaultDiagnosticsCooldown = 5 * time.Minute
+	// defaultDiagnosticsMaxFiles is the default maximal number of files kept in the directory.
+	defaultDiagnosticsMaxFiles = 30
+	// defaultDiagnosticsMaxSize is the default maximal total size of files kept in the directory.
+	defaultDiagnosticsMaxSize = 1 << 30
+	// defaultDiagnosticsHistoryWindow is the default window of statistics history stored in the snapshot.
+	defaultDiagnosticsHistoryWindow = time.Minute
+)
+
+const (
+	// diagnosticsFilePrefix is the prefix of the files created by capturer.
+	diagnosticsFilePrefix = "memlimiter-"
+	// diagnosticsTimeLayout is sortable timestamp layout used in file names.
+	diagnosticsTimeLayout = "20060102T150405.000Z"
+	// diagnosticsStatsSuffix is the suffix of the stats snapshot file.
+	diagnosticsStatsSuffix = "-stats.json"
+	// diagnosticsProfileSuffix is the suffix of the profile files.
+	diagnosticsProfileSuffix = ".pb.gz"
+)
+
+// DiagnosticsConfig - settings of the automatic capture of pprof profiles and statistics snapshot
+// when memory budget utilization reaches the configured level.
+type DiagnosticsConfig struct {
+	// Directory - directory for the captured files; it's created if it doesn't exist.
+	Directory string `json:"directory"`
+	// Utilization - memory budget utilization level triggering capture [percents].
+	Utilization uint32 `json:"utilization"`
+	// Cooldown - minimal interval between captures; while utilization stays above the level,
+	// capture is repeated at most once per Cooldown. Zero means default value (5m).
+	Cooldown duration.Duration `json:"cooldown"`
+	// MaxFiles - maximal number of files kept in the directory, the oldest captures are removed first.
+	// It must be enough for at least one capture (len(Profiles)+1 files). Zero means default value (30).
+	MaxFiles int `json:"max_files"`
+	// MaxSize - maximal total size of the files kept in the directory, the oldest captures are removed first.
+	// The latest capture is always kept, even if it exceeds the limit. Zero means default value (1G).
+	MaxSize bytes.Bytes `json:"max_size"`
+	// Profiles - names of the captured pprof profiles. Empty list means "heap" and "goroutine".
+	Profiles []string `json:"profiles"`
+	// HistoryWindow - window of statistics history preceding the capture that is stored in the snapshot
+	// (if statistics history is enabled). The capture happens near OOM, so the window should be short.
+	// Zero means default value (1m).
+	HistoryWindow duration.Duration `json:"history_window"`
+}
+
+// Prepare - config validator.
+func (c *DiagnosticsConfig) Prepare() error {
+	if c.Directory == "" {
+		return errors.New("empty Directory")
+	}
+
+	if c.Utilization == 0 {
+		return errors.New("zero Utilization")
+	}
+
+	if c.Cooldown.Duration < 0 || c.MaxFiles < 0 || c.HistoryWindow.Duration < 0 {
+		return errors.New("negative Cooldown, MaxFiles or HistoryWindow")
+	}
+
+	if c.Cooldown.Duration == 0 {
+		c.Cooldown.Duration = defaultDiagnosticsCooldown
+	}
+
+	if c.MaxFiles == 0 {
+		c.MaxFiles = defaultDiagnosticsMaxFiles
+	}
+
+	if c.MaxSize.Value == 0 {
+		c.MaxSize.Value = defaultDiagnosticsMaxSize
+	}
+
+	if c.HistoryWindow.Duration == 0 {
+		c.HistoryWindow.Duration = defaultDiagnosticsHistoryWindow
+	}
+
+	if len(c.Profiles) == 0 {
+		c.Profiles = []string{"heap", "goroutine"}
+	}
+
+	for _, name := range c.Profiles {
+		if pprof.Lookup(name) == nil {
+			return fmt.Errorf("unknown profile '%s'", name)
+		}
+	}
+
+	if c.MaxFiles < len(c.Profiles)+1 {
+		return fmt.Errorf("MaxFiles value is too small to keep a single capture (%d files)", len(c.Profiles)+1)
+	}
+
+	return nil
+}
+
+// DiagnosticsSnapshot - statistics snapshot stored along with the captured profiles.
+type DiagnosticsSnapshot struct {
+	// Time - the moment of the capture.
+	Time time.Time `json:"time"`
+	// ControlParameters - control parameters that triggered the capture.
+	ControlParameters *stats.ControlParameters `json:"control_parameters"`
+	// Stats - full MemLimiter statistics.
+	Stats *stats.MemLimiterStats `json:"stats"`
+	// History - statistics samples within DiagnosticsConfig.HistoryWindow preceding the capture
+	// (see Service.GetStatsHistory).
+	History []*StatsSample `json:"history,omitempty"`
+}
+
+// diagnosticsTrigger is the request for capture.
+type diagnosticsTrigger struct {
+	time  time.Time
+	value *stats.ControlParameters
+}
+
+// diagnosticsCapturer captures profiles in background when utilization reaches the configured level.
+type diagnosticsCapturer struct {
+	cfg *DiagnosticsConfig
+	// triggers are the pending capture requests.
+	triggers chan *diagnosticsTrigger
+	// lastCapture is the moment of the latest triggered capture.
+	lastCapture time.Time
+	// mutex protects lastCapture.
+	mutex    sync.Mutex
+	getStats func() (*stats.MemLimiterStats, error)
+	// getHistory returns statistics history within the range; may be nil.
+	getHistory func(from, to time.Time) []*StatsSample
+	breaker    *breaker.Breaker
+	logger     logr.Logger
+}
+
+func newDiagnosticsCapturer(cfg *DiagnosticsConfig) *diagnosticsCapturer {
+	return &diagnosticsCapturer{
+		cfg:      cfg,
+		triggers: make(chan *diagnosticsTrigger, 1),
+		breaker:  breaker.NewBreakerWithInitValue(1),
+	}
+}
+
+// observe requests capture if utilization is high enough and cooldown has passed; it never blocks.
+func (c *diagnosticsCapturer) observe(value *stats.ControlParameters) {
+	c.observeAt(time.Now(), value)
+}
+
+func (c *diagnosticsCapturer) observeAt(now time.Time, value *stats.ControlParameters) {
+	if value == nil || value.ControllerStats == nil || value.ControllerStats.MemoryBudget == nil {
+		return
+	}
+
+	if value.ControllerStats.MemoryBudget.Utilization*percents < float64(c.cfg.Utilization) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.lastCapture.IsZero() && now.Sub(c.lastCapture) < c.cfg.Cooldown.Duration {
+		return
+	}
+
+	select {
+	case c.triggers <- &diagnosticsTrigger{time: now, value: value}:
+		c.lastCapture = now
+	default:
+	}
+}
+
+// start runs the capturing loop.
+func (c *diagnosticsCapturer) start(
+	logger logr.Logger,
+	getStats func() (*stats.MemLimiterStats, error),
+	getHistory func(from, to time.Time) []*StatsSample,
+) {
+	c.logger = logger
+	c.getStats = getStats
+	c.getHistory = getHistory
+
+	go c.loop()
+}
+
+func (c *diagnosticsCapturer) loop() {
+	defer c.breaker.Dec()
+
+	for {
+		select {
+		case trigger := <-c.triggers:
+			files, err := c.capture(trigger)
+			if err != nil {
+				c.logger.Error(err, "capture diagnostics")
+
+				continue
+			}
+
+			c.logger.Info("diagnostics captured", "files", files)
+		case <-c.breaker.Done():
+			return
+		}
+	}
+}
+
+// capture writes profiles and statistics snapshot and removes the files exceeding limits.
+func (c *diagnosticsCapturer) capture(trigger *diagnosticsTrigger) ([]string, error) {
+	if err := os.MkdirAll(c.cfg.Directory, 0o750); err != nil {
+		return nil, fmt.Errorf("make directory: %w", err)
+	}
+
+	prefix := filepath.Join(c.cfg.Directory, diagnosticsFilePrefix+trigger.time.UTC().Format(diagnosticsTimeLayout))
+	files := make([]string, 0, len(c.cfg.Profiles)+1)
+
+	for _, name := range c.cfg.Profiles {
+		path := prefix + "-" + name + diagnosticsProfileSuffix
+
+		if err := writeDiagnosticsFile(path, func(f *os.File) error { return pprof.Lookup(name).WriteTo(f, 0) }); err != nil {
+			return files, fmt.Errorf("write profile '%s': %w", name, err)
+		}
+
+		files = append(files, path)
+	}
+
+	snapshot := &DiagnosticsSnapshot{Time: trigger.time, ControlParameters: trigger.value}
+
+	if c.getStats != nil {
+		memLimiterStats, err := c.getStats()
+		if err != nil {
+			return files, fmt.Errorf("get stats: %w", err)
+		}
+
+		snapshot.Stats = memLimiterStats
+	}
+
+	if c.getHistory != nil {
+		snapshot.History = c.getHistory(trigger.time.Add(-c.cfg.HistoryWindow.Duration), trigger.time)
+	}
+
+	path := prefix + diagnosticsStatsSuffix
+
+	// Snapshot is written without indentation to keep memory consumption low near OOM.
+	if err := writeDiagnosticsFile(path, func(f *os.File) error { return json.NewEncoder(f).Encode(snapshot) }); err != nil {
+		return files, fmt.Errorf("write stats snapshot: %w", err)
+	}
+
+	files = append(files, path)
+
+	if err := c.rotate(); err != nil {
+		return files, fmt.Errorf("rotate: %w", err)
+	}
+
+	return files, nil
+}
+
+// writeDiagnosticsFile creates file and fills it with content.
+func writeDiagnosticsFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path) //nolint:gosec // The path is built from the trusted config.
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+
+	if err := write(f); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// rotate removes the oldest captures until the number of files and their total size fit the limits.
+// Files of the same capture are removed together, and the latest capture is always kept.
+func (c *diagnosticsCapturer) rotate() error {
+	entries, err := os.ReadDir(c.cfg.Directory)
+	if err != nil {
+		return fmt.Errorf("read directory: %w", err)
+	}
+
+	type capture struct {
+		// key is the common prefix of the capture files: the file prefix and the timestamp.
+		key   string
+		names []string
+		size  uint64
+	}
+
+	var (
+		captures   []*capture
+		totalFiles int
+		totalSize  uint64
+	)
+
+	keyLength := len(diagnosticsFilePrefix) + len(diagnosticsTimeLayout)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || len(name) <= keyLength || !strings.HasPrefix(name, diagnosticsFilePrefix) ||
+			!(strings.HasSuffix(name, diagnosticsProfileSuffix) || strings.HasSuffix(name, diagnosticsStatsSuffix)) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("file info: %w", err)
+		}
+
+		size := uint64(info.Size()) //nolint:gosec // Size is never negative.
+
+		// directory entries are sorted by name and names start with sortable timestamp,
+		// so the files of the same capture are adjacent and the oldest captures go first
+		if key := name[:keyLength]; len(captures) == 0 || captures[len(captures)-1].key != key {
+			captures = append(captures, &capture{key: key})
+		}
+
+		last := captures[len(captures)-1]
+		last.names = append(last.names, name)
+		last.size += size
+
+		totalFiles++
+		totalSize += size
+	}
+
+	for len(captures) > 1 && (totalFiles > c.cfg.MaxFiles || totalSize > c.cfg.MaxSize.Value) {
+		for _, name := range captures[0].names {
+			if err := os.Remove(filepath.Join(c.cfg.Directory, name)); err != nil {
+				return fmt.Errorf("remove file: %w", err)
+			}
+		}
+
+		totalFiles -= len(captures[0].names)
+		totalSize -= captures[0].size
+		captures = captures[1:]
+	}
+
+	return nil
+}
+
+// quit stops the capturing loop.
+func (c *diagnosticsCapturer) quit() {
+	c.breaker.ShutdownAndWait()
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/bytes"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnosticsConfig(t *testing.T) {
+	cfg := &DiagnosticsConfig{Directory: t.TempDir(), Utilization: 90}
+	require.NoError(t, cfg.Prepare())
+	require.Equal(t, defaultDiagnosticsCooldown, cfg.Cooldown.Duration)
+	require.Equal(t, []string{"heap", "goroutine"}, cfg.Profiles)
+	require.Equal(t, defaultDiagnosticsHistoryWindow, cfg.HistoryWindow.Duration)
+
+	require.Error(t, (&DiagnosticsConfig{Utilization: 90}).Prepare())
+	require.Error(t, (&DiagnosticsConfig{Directory: t.TempDir()}).Prepare())
+	require.Error(t, (&DiagnosticsConfig{Directory: t.TempDir(), Utilization: 90, Profiles: []string{"unknown"}}).Prepare())
+	// a single capture takes three files
+	require.Error(t, (&DiagnosticsConfig{Directory: t.TempDir(), Utilization: 90, MaxFiles: 2}).Prepare())
+	require.Error(t, (&DiagnosticsConfig{
+		Directory:     t.TempDir(),
+		Utilization:   90,
+		HistoryWindow: duration.Duration{Duration: -time.Second},
+	}).Prepare())
+}
+
+func TestDiagnosticsCapturer(t *testing.T) {
+	t.Run("cooldown", func(t *testing.T) {
+		cfg := &DiagnosticsConfig{
+			Directory:   t.TempDir(),
+			Utilization: 90,
+			Cooldown:    duration.Duration{Duration: time.Minute},
+		}
+		require.NoError(t, cfg.Prepare())
+
+		c := newDiagnosticsCapturer(cfg)
+		start := time.Unix(1000, 0)
+
+		c.observeAt(start, makeControlParameters(50, 0, stats.ZoneGOGC, 0.8))
+		require.Empty(t, c.triggers)
+
+		c.observeAt(start, makeControlParameters(20, 30, stats.ZoneThrottling, 0.95))
+		require.Len(t, c.triggers, 1)
+
+		<-c.triggers
+
+		c.observeAt(start.Add(30*time.Second), makeControlParameters(20, 30, stats.ZoneThrottling, 0.95))
+		require.Empty(t, c.triggers)
+
+		c.observeAt(start.Add(time.Minute), makeControlParameters(20, 30, stats.ZoneThrottling, 0.95))
+		require.Len(t, c.triggers, 1)
+	})
+
+	t.Run("capture", func(t *testing.T) {
+		cfg := &DiagnosticsConfig{Directory: filepath.Join(t.TempDir(), "diagnostics"), Utilization: 90}
+		require.NoError(t, cfg.Prepare())
+
+		c := newDiagnosticsCapturer(cfg)
+		c.start(testr.New(t), func() (*stats.MemLimiterStats, error) {
+			return &stats.MemLimiterStats{Admission: &stats.AdmissionStats{Admitted: 10}}, nil
+		}, func(from, to time.Time) []*StatsSample {
+			// only the configured window of history is requested
+			if !from.Equal(to.Add(-defaultDiagnosticsHistoryWindow)) {
+				return nil
+			}
+
+			return []*StatsSample{{Time: time.Unix(999, 0), Stats: &stats.MemLimiterStats{}}}
+		})
+
+		defer c.quit()
+
+		c.observeAt(time.Unix(1000, 0), makeControlParameters(20, 30, stats.ZoneThrottling, 0.95))
+
+		statsFile := filepath.Join(cfg.Directory, "memlimiter-19700101T001640.000Z-stats.json")
+
+		require.Eventually(t, func() bool {
+			_, err := os.Stat(statsFile)
+
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		for _, name := range []string{"heap", "goroutine"} {
+			info, err := os.Stat(filepath.Join(cfg.Directory, "memlimiter-19700101T001640.000Z-"+name+".pb.gz"))
+			require.NoError(t, err)
+			require.NotZero(t, info.Size())
+		}
+
+		data, err := os.ReadFile(statsFile)
+		require.NoError(t, err)
+
+		var snapshot DiagnosticsSnapshot
+		require.NoError(t, json.Unmarshal(data, &snapshot))
+		require.Equal(t, uint32(30), snapshot.ControlParameters.ThrottlingPercentage)
+		require.Equal(t, uint64(10), snapshot.Stats.Admission.Admitted)
+		require.Len(t, snapshot.History, 1)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		cfg := &DiagnosticsConfig{Directory: t.TempDir(), Utilization: 90, MaxFiles: 7}
+		require.NoError(t, cfg.Prepare())
+
+		c := newDiagnosticsCapturer(cfg)
+		start := time.Unix(1000, 0)
+
+		for i := range 3 {
+			_, err := c.capture(&diagnosticsTrigger{
+				time:  start.Add(time.Duration(i) * time.Second),
+				value: makeControlParameters(20, 30, stats.ZoneThrottling, 0.95),
+			})
+			require.NoError(t, err)
+		}
+
+		// foreign files are never removed
+		require.NoError(t, os.WriteFile(filepath.Join(cfg.Directory, "notes.txt"), []byte("keep"), 0o600))
+
+		// captures are removed as a whole: the two newest ones (3 files each) are kept
+		entries, err := os.ReadDir(cfg.Directory)
+		require.NoError(t, err)
+		require.Len(t, entries, 7)
+
+		for _, name := range []string{"heap.pb.gz", "goroutine.pb.gz", "stats.json"} {
+			_, err = os.Stat(filepath.Join(cfg.Directory, "memlimiter-19700101T001642.000Z-"+name))
+			require.NoError(t, err)
+			_, err = os.Stat(filepath.Join(cfg.Directory, "memlimiter-19700101T001641.000Z-"+name))
+			require.NoError(t, err)
+			_, err = os.Stat(filepath.Join(cfg.Directory, "memlimiter-19700101T001640.000Z-"+name))
+			require.ErrorIs(t, err, os.ErrNotExist)
+		}
+
+		// the latest capture is kept even if it exceeds the size limit
+		cfg.MaxSize = bytes.Bytes{Value: 1}
+		require.NoError(t, c.rotate())
+
+		entries, err = os.ReadDir(cfg.Directory)
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+
+		_, err = os.Stat(filepath.Join(cfg.Directory, "memlimiter-19700101T001642.000Z-stats.json"))
+		require.NoError(t, err)
+	})
+}
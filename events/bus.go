@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package events
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Subscription - a stream of events delivered to a single subscriber.
+type Subscription interface {
+	// Events returns the stream of events. The channel is closed after unsubscription
+	// or when the MemLimiter service terminates.
+	Events() <-chan *Event
+	// Dropped returns the number of events that have not been delivered because subscriber was busy.
+	Dropped() uint64
+	// Unsubscribe stops events delivery.
+	Unsubscribe()
+}
+
+// Bus delivers events to any number of subscribers.
+// Publisher never blocks: slow subscribers lose events according to the delivery mode they chose.
+// It is safe for concurrent use.
+type Bus struct {
+	// subscriptions are the active subscriptions.
+	subscriptions map[*subscriptionImpl]struct{}
+	// closed is set when bus is closed.
+	closed bool
+	// mutex protects subscriptions and closed flag.
+	mutex sync.RWMutex
+}
+
+// NewBus constructs a new Bus.
+func NewBus() *Bus {
+	return &Bus{
+		subscriptions: make(map[*subscriptionImpl]struct{}),
+	}
+}
+
+// Subscribe creates a new subscription. Subscription created after the bus closure
+// receives no events, and its channel is closed immediately.
+func (b *Bus) Subscribe(options ...SubscribeOption) Subscription {
+	out := &subscriptionImpl{bus: b}
+
+	bufferSize := defaultBufferSize
+
+	for _, op := range options {
+		switch t := op.(type) {
+		case *latestDeliveryOption:
+			out.latest = true
+		case *bufferedDeliveryOption:
+			out.latest = false
+			bufferSize = t.val
+		case *kindsOption:
+			out.kinds = t.val
+		}
+	}
+
+	if out.latest || bufferSize < 1 {
+		bufferSize = 1
+	}
+
+	out.events = make(chan *Event, bufferSize)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		close(out.events)
+
+		return out
+	}
+
+	b.subscriptions[out] = struct{}{}
+
+	return out
+}
+
+// Publish delivers event to all the subscribers.
+func (b *Bus) Publish(event *Event) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for s := range b.subscriptions {
+		s.deliver(event)
+	}
+}
+
+// Close terminates all the subscriptions.
+func (b *Bus) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+
+	for s := range b.subscriptions {
+		close(s.events)
+		delete(b.subscriptions, s)
+	}
+}
+
+// unsubscribe removes subscription from the bus.
+func (b *Bus) unsubscribe(s *subscriptionImpl) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.subscriptions[s]; !ok {
+		return
+	}
+
+	close(s.events)
+	delete(b.subscriptions, s)
+}
+
+var _ Subscription = (*subscriptionImpl)(nil)
+
+// subscriptionImpl is the implementation of the Subscription interface.
+type subscriptionImpl struct {
+	// events is the outgoing stream.
+	events chan *Event
+	// kinds are the event types subscriber is interested in (all types, if empty).
+	kinds []Kind
+	// latest enables latest-value delivery mode.
+	latest bool
+	// dropped is the number of events that have not been delivered.
+	dropped atomic.Uint64
+	// mutex serializes deliveries in latest-value mode.
+	mutex sync.Mutex
+	bus   *Bus
+}
+
+func (s *subscriptionImpl) Events() <-chan *Event { return s.events }
+
+func (s *subscriptionImpl) Dropped() uint64 { return s.dropped.Load() }
+
+func (s *subscriptionImpl) Unsubscribe() { s.bus.unsubscribe(s) }
+
+// deliver sends event to subscriber without blocking.
+func (s *subscriptionImpl) deliver(event *Event) {
+	if len(s.kinds) > 0 && !slices.Contains(s.kinds, event.Kind) {
+		return
+	}
+
+	if !s.latest {
+		select {
+		case s.events <- event:
+		default:
+			s.dropped.Add(1)
+		}
+
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Replace the stale event with the actual one.
+	for {
+		select {
+		case s.events <- event:
+			return
+		default:
+		}
+
+		select {
+		case <-s.events:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	t.Run("buffered delivery", func(t *testing.T) {
+		bus := NewBus()
+		defer bus.Close()
+
+		sub := bus.Subscribe(WithBufferedDelivery(2))
+
+		for _, kind := range []Kind{KindZoneEntered, KindZoneLeft, KindCritical} {
+			bus.Publish(&Event{Kind: kind})
+		}
+
+		require.Equal(t, KindZoneEntered, (<-sub.Events()).Kind)
+		require.Equal(t, KindZoneLeft, (<-sub.Events()).Kind)
+		require.Equal(t, uint64(1), sub.Dropped())
+	})
+
+	t.Run("latest delivery", func(t *testing.T) {
+		bus := NewBus()
+		defer bus.Close()
+
+		sub := bus.Subscribe(WithLatestDelivery())
+
+		for _, kind := range []Kind{KindZoneEntered, KindZoneLeft, KindCritical} {
+			bus.Publish(&Event{Kind: kind})
+		}
+
+		require.Equal(t, KindCritical, (<-sub.Events()).Kind)
+		require.Equal(t, uint64(2), sub.Dropped())
+	})
+
+	t.Run("kinds filter", func(t *testing.T) {
+		bus := NewBus()
+		defer bus.Close()
+
+		sub := bus.Subscribe(WithKinds(KindCritical))
+
+		bus.Publish(&Event{Kind: KindZoneEntered})
+		bus.Publish(&Event{Kind: KindCritical})
+
+		require.Equal(t, KindCritical, (<-sub.Events()).Kind)
+		require.Empty(t, sub.Events())
+		require.Zero(t, sub.Dropped())
+	})
+
+	t.Run("multiple subscribers and unsubscription", func(t *testing.T) {
+		bus := NewBus()
+
+		first, second := bus.Subscribe(), bus.Subscribe()
+
+		bus.Publish(&Event{Kind: KindZoneEntered})
+
+		require.Equal(t, KindZoneEntered, (<-first.Events()).Kind)
+		require.Equal(t, KindZoneEntered, (<-second.Events()).Kind)
+
+		first.Unsubscribe()
+		first.Unsubscribe()
+
+		_, ok := <-first.Events()
+		require.False(t, ok)
+
+		bus.Close()
+
+		_, ok = <-second.Events()
+		require.False(t, ok)
+
+		// Subscriptions made after closure are closed immediately.
+		_, ok = <-bus.Subscribe().Events()
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,9 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+// Package events contains event bus delivering MemLimiter state changes
+// (memory budget utilization zones, control parameters) to any number of subscribers.
+package events
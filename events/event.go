@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package events
+
+import (
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+// Kind - event type.
+type Kind int
+
+const (
+	// KindZoneEntered - memory budget utilization has entered a new zone.
+	KindZoneEntered Kind = iota + 1
+	// KindZoneLeft - memory budget utilization has left the zone.
+	KindZoneLeft
+	// KindControlParametersChanged - controller has issued new control parameters.
+	KindControlParametersChanged
+	// KindCritical - memory budget has been exhausted.
+	KindCritical
+)
+
+// String returns event type name.
+func (k Kind) String() string {
+	switch k {
+	case KindZoneEntered:
+		return "zone_entered"
+	case KindZoneLeft:
+		return "zone_left"
+	case KindControlParametersChanged:
+		return "control_parameters_changed"
+	case KindCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// Event - MemLimiter state change.
+type Event struct {
+	// Time - the moment of the event.
+	Time time.Time
+	// ControlParameters - actual control parameters (including controller statistics).
+	ControlParameters *stats.ControlParameters
+	// Kind - event type.
+	Kind Kind
+	// Zone - the zone that has been entered or left; for other event types it's the actual zone.
+	Zone stats.Zone
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package events
+
+// defaultBufferSize is the default size of the subscription buffer.
+const defaultBufferSize = 64
+
+// SubscribeOption - subscription options.
+type SubscribeOption interface {
+	anchor()
+}
+
+type latestDeliveryOption struct{}
+
+func (o latestDeliveryOption) anchor() {}
+
+// WithLatestDelivery makes subscription keep only the latest undelivered event:
+// if subscriber is busy, the previous event is replaced with the new one and counted as dropped.
+// That's convenient for subscribers that are interested only in the actual state.
+func WithLatestDelivery() SubscribeOption {
+	return &latestDeliveryOption{}
+}
+
+type bufferedDeliveryOption struct {
+	val int
+}
+
+func (o bufferedDeliveryOption) anchor() {}
+
+// WithBufferedDelivery makes subscription queue up to size undelivered events:
+// if the buffer is full, new events are counted as dropped.
+// This is the default delivery mode (with buffer size 64).
+func WithBufferedDelivery(size int) SubscribeOption {
+	return &bufferedDeliveryOption{val: size}
+}
+
+type kindsOption struct {
+	val []Kind
+}
+
+func (o kindsOption) anchor() {}
+
+// WithKinds limits the subscription with the given event types.
+func WithKinds(kinds ...Kind) SubscribeOption {
+	return &kindsOption{val: kinds}
+}
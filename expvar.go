@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/newcloudtechnologies/memlimiter/utils/breaker"
+)
+
+// ExpvarState - the value published with expvar (see WithExpvar).
+type ExpvarState struct {
+	// UpdatedAt - the moment of the snapshot.
+	UpdatedAt time.Time `json:"updated_at"`
+	// Zone - memory budget utilization zone.
+	Zone string `json:"zone"`
+	// Utilization - memory budget utilization ratio.
+	Utilization float64 `json:"utilization"`
+	// ShedShare - share of throttled requests within the shortest throttling window (in range [0; 1]).
+	ShedShare float64 `json:"shed_share"`
+	// ShedRate - throttled requests per second within the shortest throttling window.
+	ShedRate float64 `json:"shed_rate"`
+	// Stats - full MemLimiter statistics.
+	Stats *stats.MemLimiterStats `json:"stats"`
+}
+
+// expvarVars are the variables published by MemLimiter [key - name]. Since expvar doesn't support
+// unpublishing, variables are reused by the services created with the same name later.
+var expvarVars sync.Map
+
+// expvarVar is expvar.Var rendering the latest snapshot, so that reading it never blocks MemLimiter.
+type expvarVar struct {
+	snapshot atomic.Pointer[[]byte]
+}
+
+// String returns the latest snapshot.
+func (v *expvarVar) String() string {
+	snapshot := v.snapshot.Load()
+	if snapshot == nil {
+		return "null"
+	}
+
+	return string(*snapshot)
+}
+
+// publishExpvar returns variable published with the name.
+func publishExpvar(name string) (*expvarVar, error) {
+	if existing, ok := expvarVars.Load(name); ok {
+		//nolint:forcetypeassert // Only *expvarVar values are stored.
+		return existing.(*expvarVar), nil
+	}
+
+	if expvar.Get(name) != nil {
+		return nil, fmt.Errorf("expvar '%s' is already published by someone else", name)
+	}
+
+	actual, loaded := expvarVars.LoadOrStore(name, &expvarVar{})
+	//nolint:forcetypeassert // Only *expvarVar values are stored.
+	v := actual.(*expvarVar)
+
+	if !loaded {
+		expvar.Publish(name, v)
+	}
+
+	return v, nil
+}
+
+// expvarPublisher refreshes the snapshot after every controller update.
+type expvarPublisher struct {
+	v *expvarVar
+	// updates signals that the snapshot must be refreshed.
+	updates  chan struct{}
+	getStats func() (*stats.MemLimiterStats, error)
+	breaker  *breaker.Breaker
+	logger   logr.Logger
+}
+
+func newExpvarPublisher(name string) (*expvarPublisher, error) {
+	v, err := publishExpvar(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &expvarPublisher{
+		v:       v,
+		updates: make(chan struct{}, 1),
+		breaker: breaker.NewBreakerWithInitValue(1),
+	}, nil
+}
+
+// notify requests snapshot refresh; it never blocks.
+func (p *expvarPublisher) notify() {
+	select {
+	case p.updates <- struct{}{}:
+	default:
+	}
+}
+
+// start runs the refreshing loop.
+func (p *expvarPublisher) start(logger logr.Logger, getStats func() (*stats.MemLimiterStats, error)) {
+	p.logger = logger
+	p.getStats = getStats
+
+	go p.loop()
+}
+
+func (p *expvarPublisher) loop() {
+	defer p.breaker.Dec()
+
+	for {
+		select {
+		case <-p.updates:
+			if err := p.refresh(); err != nil {
+				p.logger.Error(err, "refresh expvar snapshot")
+			}
+		case <-p.breaker.Done():
+			return
+		}
+	}
+}
+
+// refresh renders the actual state.
+func (p *expvarPublisher) refresh() error {
+	memLimiterStats, err := p.getStats()
+	if err != nil {
+		return fmt.Errorf("get stats: %w", err)
+	}
+
+	data, err := json.Marshal(newExpvarState(time.Now(), memLimiterStats))
+	if err != nil {
+		return fmt.Errorf("marshal state: %w", err)
+	}
+
+	p.v.snapshot.Store(&data)
+
+	return nil
+}
+
+// quit stops the refreshing loop; the latest snapshot remains published.
+func (p *expvarPublisher) quit() {
+	p.breaker.ShutdownAndWait()
+}
+
+// newExpvarState derives state from statistics.
+func newExpvarState(now time.Time, memLimiterStats *stats.MemLimiterStats) *ExpvarState {
+	out := &ExpvarState{
+		UpdatedAt: now,
+		Stats:     memLimiterStats,
+	}
+
+	if memLimiterStats == nil {
+		return out
+	}
+
+	if cs := memLimiterStats.Controller; cs != nil && cs.MemoryBudget != nil {
+		out.Zone = cs.MemoryBudget.Zone.String()
+		out.Utilization = cs.MemoryBudget.Utilization
+	}
+
+	if bp := memLimiterStats.Backpressure; bp != nil && bp.Throttling != nil && len(bp.Throttling.Windows) > 0 {
+		window := bp.Throttling.Windows[0]
+		out.ShedShare = window.ThrottledShare
+		out.ShedRate = window.ThrottledRate
+	}
+
+	return out
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpvarPublisher(t *testing.T) {
+	t.Run("snapshot", func(t *testing.T) {
+		const name = "memlimiter_test_snapshot"
+
+		publisher, err := newExpvarPublisher(name)
+		require.NoError(t, err)
+
+		require.Equal(t, "null", expvar.Get(name).String())
+
+		publisher.start(testr.New(t), func() (*stats.MemLimiterStats, error) {
+			return &stats.MemLimiterStats{
+				Controller: &stats.ControllerStats{
+					MemoryBudget: &stats.MemoryBudgetStats{Utilization: 0.95, Zone: stats.ZoneThrottling},
+				},
+				Backpressure: &stats.BackpressureStats{
+					Throttling: &stats.ThrottlingStats{
+						Windows: []*stats.ThrottlingWindowStats{
+							{Window: time.Second, ThrottledShare: 0.3, ThrottledRate: 12},
+							{Window: time.Minute, ThrottledShare: 0.1, ThrottledRate: 4},
+						},
+					},
+				},
+			}, nil
+		})
+		defer publisher.quit()
+
+		publisher.notify()
+
+		require.Eventually(t, func() bool { return expvar.Get(name).String() != "null" }, time.Second, time.Millisecond)
+
+		var state ExpvarState
+		require.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &state))
+		require.Equal(t, "throttling", state.Zone)
+		require.InDelta(t, 0.95, state.Utilization, 0)
+		require.InDelta(t, 0.3, state.ShedShare, 0)
+		require.InDelta(t, 12, state.ShedRate, 0)
+		require.NotNil(t, state.Stats)
+		require.False(t, state.UpdatedAt.IsZero())
+	})
+
+	t.Run("name reuse", func(t *testing.T) {
+		const name = "memlimiter_test_reuse"
+
+		first, err := publishExpvar(name)
+		require.NoError(t, err)
+
+		second, err := publishExpvar(name)
+		require.NoError(t, err)
+		require.Same(t, first, second)
+	})
+
+	t.Run("foreign name", func(t *testing.T) {
+		const name = "memlimiter_test_foreign"
+
+		expvar.NewInt(name)
+
+		_, err := newExpvarPublisher(name)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// percents is a constant for converting utilization ratio to percents.
+const percents = 100
+
+// Checker is an Operator that switches service health status according to memory budget utilization:
+// service becomes NOT_SERVING when utilization reaches critical zone, and the status set by the application
+// is restored when utilization falls below recovery zone. It is supposed to be combined with the default operator:
+//
+//	checker, err := health.NewChecker(logger, cfg, health.WithGRPCHealthServer(healthServer))
+//	operator := backpressure.NewCompositeOperator(backpressure.NewOperator(logger), checker)
+type Checker interface {
+	backpressure.Operator
+	// Serving returns true if service is healthy.
+	Serving() bool
+	// SetServingStatus sets the status of the service in gRPC health server on behalf of the application.
+	// While the checker keeps the service NOT_SERVING, the status is saved and applied once memory
+	// pressure is gone, so the application should use this method instead of updating gRPC health server directly.
+	SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus)
+	// MakeHTTPHandler returns readiness probe handler: it responds with 200 (OK) when service is healthy
+	// and with 503 (Service Unavailable) otherwise.
+	MakeHTTPHandler() http.Handler
+}
+
+var _ Checker = (*checkerImpl)(nil)
+
+// checkerImpl is the implementation of the Checker interface.
+type checkerImpl struct {
+	cfg          *Config
+	healthServer *health.Server
+	// serving is the status according to memory budget utilization.
+	serving bool
+	// shadow is true if checker runs in the shadow (dry-run) mode: service is always reported healthy.
+	shadow bool
+	// transitions is the number of status changes.
+	transitions uint64
+	// shadowTransitions is the number of status changes that would have happened in the shadow mode.
+	shadowTransitions uint64
+	// lastTransition is the time of the latest status change.
+	lastTransition time.Time
+	// saved are the statuses set by the application for the services the checker has overridden
+	// with NOT_SERVING [key - service].
+	saved map[string]healthpb.HealthCheckResponse_ServingStatus
+	// mutex protects the state.
+	mutex  sync.RWMutex
+	logger logr.Logger
+}
+
+// NewChecker constructs a new Checker. Service is considered healthy initially;
+// the statuses in gRPC health server are left intact until memory pressure occurs.
+func NewChecker(logger logr.Logger, cfg *Config, options ...Option) (Checker, error) {
+	if cfg == nil {
+		return nil, errors.New("nil config")
+	}
+
+	if err := cfg.Prepare(); err != nil {
+		return nil, fmt.Errorf("prepare config: %w", err)
+	}
+
+	out := &checkerImpl{
+		cfg:     cfg,
+		serving: true,
+		logger:  logger,
+	}
+
+	for _, op := range options {
+		//nolint:gocritic
+		switch t := op.(type) {
+		case *grpcHealthServerOption:
+			out.healthServer = t.val
+		case *shadowModeOption:
+			out.shadow = true
+		}
+	}
+
+	return out, nil
+}
+
+// SetControlParameters updates health status according to memory budget utilization.
+func (c *checkerImpl) SetControlParameters(value *stats.ControlParameters) error {
+	if value == nil || value.ControllerStats == nil || value.ControllerStats.MemoryBudget == nil {
+		return nil
+	}
+
+	utilization := value.ControllerStats.MemoryBudget.Utilization * percents
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch {
+	case c.serving && utilization >= float64(c.cfg.CriticalZone):
+		c.serving = false
+	case !c.serving && utilization < float64(c.cfg.RecoveryZone):
+		c.serving = true
+	default:
+		return nil
+	}
+
+	if c.shadow {
+		c.shadowTransitions++
+		c.logger.Info("health status would have changed", "serving", c.serving, "utilization", utilization)
+
+		return nil
+	}
+
+	if c.serving {
+		c.restoreServingStatus()
+	} else {
+		c.overrideServingStatus()
+	}
+
+	c.transitions++
+	c.lastTransition = time.Now()
+
+	c.logger.Info("health status changed", "serving", c.serving, "utilization", utilization)
+
+	return nil
+}
+
+// overrideServingStatus makes the configured services NOT_SERVING in gRPC health server,
+// saving their actual statuses. Services unknown to gRPC health server are left unregistered.
+func (c *checkerImpl) overrideServingStatus() {
+	if c.healthServer == nil {
+		return
+	}
+
+	c.saved = make(map[string]healthpb.HealthCheckResponse_ServingStatus, len(c.cfg.Services))
+
+	for _, service := range c.cfg.Services {
+		status := c.servingStatus(service)
+		if status == healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+			continue
+		}
+
+		c.saved[service] = status
+		c.healthServer.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+}
+
+// restoreServingStatus restores the statuses saved by overrideServingStatus
+// (or set by the application with SetServingStatus in the meantime).
+func (c *checkerImpl) restoreServingStatus() {
+	if c.healthServer == nil {
+		return
+	}
+
+	for service, status := range c.saved {
+		c.healthServer.SetServingStatus(service, status)
+	}
+
+	c.saved = nil
+}
+
+// SetServingStatus sets the status of the service on behalf of the application.
+func (c *checkerImpl) SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus) {
+	if c.healthServer == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.saved == nil || !slices.Contains(c.cfg.Services, service) {
+		c.healthServer.SetServingStatus(service, status)
+
+		return
+	}
+
+	c.saved[service] = status
+	c.healthServer.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// servingStatus returns the actual status of the service in gRPC health server.
+func (c *checkerImpl) servingStatus(service string) healthpb.HealthCheckResponse_ServingStatus {
+	resp, err := c.healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+
+	return resp.GetStatus()
+}
+
+// Serving returns true if service is healthy.
+func (c *checkerImpl) Serving() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.serving || c.shadow
+}
+
+// MakeHTTPHandler returns readiness probe handler.
+func (c *checkerImpl) MakeHTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if c.Serving() {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("ok\n"))
+
+			return
+		}
+
+		http.Error(w, "memory pressure", http.StatusServiceUnavailable)
+	})
+}
+
+// AllowRequest always allows requests, because checker doesn't throttle anything.
+func (c *checkerImpl) AllowRequest() bool { return true }
+
+// GetStats returns health statistics.
+func (c *checkerImpl) GetStats() (*stats.BackpressureStats, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return &stats.BackpressureStats{
+		Health: &stats.HealthStats{
+			Serving:           c.serving || c.shadow,
+			Transitions:       c.transitions,
+			LastTransition:    c.lastTransition,
+			ShadowTransitions: c.shadowTransitions,
+		},
+	}, nil
+}
+
+// Quit does nothing, since checker has no background activity.
+func (c *checkerImpl) Quit() {}
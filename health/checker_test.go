@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func makeControlParameters(utilization float64) *stats.ControlParameters {
+	return &stats.ControlParameters{
+		ControllerStats: &stats.ControllerStats{
+			MemoryBudget: &stats.MemoryBudgetStats{Utilization: utilization},
+		},
+	}
+}
+
+func TestChecker(t *testing.T) {
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("test.Service", healthpb.HealthCheckResponse_SERVING)
+
+	checker, err := NewChecker(
+		testr.New(t),
+		&Config{CriticalZone: 90, RecoveryZone: 70, Services: []string{"test.Service"}},
+		WithGRPCHealthServer(healthServer),
+	)
+	require.NoError(t, err)
+
+	requireStatus := func(serving bool) {
+		t.Helper()
+
+		expected := healthpb.HealthCheckResponse_SERVING
+		expectedCode := http.StatusOK
+
+		if !serving {
+			expected = healthpb.HealthCheckResponse_NOT_SERVING
+			expectedCode = http.StatusServiceUnavailable
+		}
+
+		resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "test.Service"})
+		require.NoError(t, err)
+		require.Equal(t, expected, resp.GetStatus())
+		require.Equal(t, serving, checker.Serving())
+
+		recorder := httptest.NewRecorder()
+		checker.MakeHTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		require.Equal(t, expectedCode, recorder.Code)
+	}
+
+	requireStatus(true)
+
+	require.NoError(t, checker.SetControlParameters(makeControlParameters(0.8)))
+	requireStatus(true)
+
+	require.NoError(t, checker.SetControlParameters(makeControlParameters(0.95)))
+	requireStatus(false)
+
+	// Hysteresis: status doesn't change until utilization falls below recovery zone.
+	require.NoError(t, checker.SetControlParameters(makeControlParameters(0.8)))
+	requireStatus(false)
+
+	require.NoError(t, checker.SetControlParameters(makeControlParameters(0.6)))
+	requireStatus(true)
+
+	backpressureStats, err := checker.GetStats()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), backpressureStats.Health.Transitions)
+}
+
+func TestCheckerRestoresApplicationStatus(t *testing.T) {
+	healthServer := health.NewServer()
+	// The application is warming up.
+	healthServer.SetServingStatus("test.Service", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	checker, err := NewChecker(
+		testr.New(t),
+		&Config{CriticalZone: 90, RecoveryZone: 70, Services: []string{"test.Service", "unknown.Service"}},
+		WithGRPCHealthServer(healthServer),
+	)
+	require.NoError(t, err)
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+
+		resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+
+		return resp.GetStatus()
+	}
+
+	// Construction doesn't change the status set by the application.
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status("test.Service"))
+
+	require.NoError(t, checker.SetControlParameters(makeControlParameters(0.95)))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status("test.Service"))
+	// Services unknown to gRPC health server are not registered by the checker.
+	require.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, status("unknown.Service"))
+
+	// The application has warmed up during memory pressure: the status is applied after recovery.
+	checker.SetServingStatus("test.Service", healthpb.HealthCheckResponse_SERVING)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status("test.Service"))
+
+	require.NoError(t, checker.SetControlParameters(makeControlParameters(0.6)))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, status("test.Service"))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, status("unknown.Service"))
+
+	// The application is shutting down during memory pressure: NOT_SERVING is kept after recovery.
+	require.NoError(t, checker.SetControlParameters(makeControlParameters(0.95)))
+	checker.SetServingStatus("test.Service", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	require.NoError(t, checker.SetControlParameters(makeControlParameters(0.6)))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status("test.Service"))
+
+	// Without memory pressure the status is set immediately.
+	checker.SetServingStatus("test.Service", healthpb.HealthCheckResponse_SERVING)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, status("test.Service"))
+}
+
+func TestCheckerShadowMode(t *testing.T) {
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("test.Service", healthpb.HealthCheckResponse_SERVING)
+
+	checker, err := NewChecker(
+		testr.New(t),
+		&Config{CriticalZone: 90, RecoveryZone: 70, Services: []string{"test.Service"}},
+		WithGRPCHealthServer(healthServer),
+		WithShadowMode(),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, checker.SetControlParameters(makeControlParameters(0.95)))
+
+	resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "test.Service"})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	require.True(t, checker.Serving())
+
+	recorder := httptest.NewRecorder()
+	checker.MakeHTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.NoError(t, checker.SetControlParameters(makeControlParameters(0.6)))
+
+	backpressureStats, err := checker.GetStats()
+	require.NoError(t, err)
+	require.Zero(t, backpressureStats.Health.Transitions)
+	require.Equal(t, uint64(2), backpressureStats.Health.ShadowTransitions)
+}
+
+func TestConfig(t *testing.T) {
+	cfg := &Config{CriticalZone: 90, RecoveryZone: 70}
+	require.NoError(t, cfg.Prepare())
+	require.Equal(t, []string{""}, cfg.Services)
+
+	for _, cfg := range []*Config{
+		{CriticalZone: 0, RecoveryZone: 70},
+		{CriticalZone: 101, RecoveryZone: 70},
+		{CriticalZone: 90, RecoveryZone: 90},
+		{CriticalZone: 90},
+	} {
+		require.Error(t, cfg.Prepare())
+	}
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package health
+
+import (
+	"errors"
+)
+
+// Config - health reporting configuration.
+type Config struct {
+	// CriticalZone - memory budget utilization threshold that makes service NOT_SERVING.
+	// Possible values are in range (0; 100].
+	CriticalZone uint32 `json:"critical_zone"`
+	// RecoveryZone - memory budget utilization threshold that makes service SERVING again.
+	// Must be less than CriticalZone, the gap between them prevents status flapping.
+	RecoveryZone uint32 `json:"recovery_zone"`
+	// Services - names of the services registered in gRPC health server.
+	// Empty list means the overall server status (empty service name).
+	Services []string `json:"services"`
+}
+
+// Prepare - config validator.
+func (c *Config) Prepare() error {
+	if c.CriticalZone == 0 || c.CriticalZone > 100 {
+		return errors.New("invalid CriticalZone value (must belong to (0; 100])")
+	}
+
+	if c.RecoveryZone == 0 || c.RecoveryZone >= c.CriticalZone {
+		return errors.New("invalid RecoveryZone value (must belong to (0; CriticalZone))")
+	}
+
+	if len(c.Services) == 0 {
+		c.Services = []string{""}
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+// Package health reports service health (gRPC health service, HTTP readiness probe) according to
+// memory pressure, so that load balancers stop routing traffic to the instances running out of memory.
+package health
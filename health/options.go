@@ -0,0 +1,37 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package health
+
+import (
+	"google.golang.org/grpc/health"
+)
+
+// Option - checker constructor options.
+type Option interface {
+	anchor()
+}
+
+type grpcHealthServerOption struct {
+	val *health.Server
+}
+
+func (o grpcHealthServerOption) anchor() {}
+
+// WithGRPCHealthServer makes checker update service statuses in the standard gRPC health server.
+func WithGRPCHealthServer(server *health.Server) Option {
+	return &grpcHealthServerOption{val: server}
+}
+
+type shadowModeOption struct{}
+
+func (o shadowModeOption) anchor() {}
+
+// WithShadowMode makes checker run in the shadow (dry-run) mode: service is always reported healthy,
+// and the status changes that would have happened are only counted.
+func WithShadowMode() Option {
+	return &shadowModeOption{}
+}
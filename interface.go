@@ -7,6 +7,10 @@
 package memlimiter
 
 import (
+	"context"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/events"
 	"github.com/newcloudtechnologies/memlimiter/middleware"
 	"github.com/newcloudtechnologies/memlimiter/stats"
 )
@@ -15,6 +19,24 @@ import (
 type Service interface {
 	Middleware() middleware.Middleware
 	GetStats() (*stats.MemLimiterStats, error)
+	// GetStatsHistory returns periodic statistics samples taken within [from; to] from the oldest
+	// to the newest; zero from or to means the range is not bounded from the corresponding side.
+	// The number of samples and the interval between them are set with Config.StatsHistory;
+	// if it's not set, no history is kept and nothing is returned.
+	GetStatsHistory(from, to time.Time) ([]*StatsSample, error)
+	// Subscribe creates a subscription for MemLimiter state change events
+	// (zone entered or left, control parameters changed, memory budget exhausted).
+	// Unlike backpressure.WithNotificationsOption, any number of subscribers is supported,
+	// and every subscriber chooses its own delivery mode.
+	Subscribe(options ...events.SubscribeOption) events.Subscription
+	// Admit is a transport-neutral admission gate for the work that is not served by middleware
+	// (queue consumers, batch jobs etc.). It blocks while backpressure operator throttles requests
+	// and fails when the context is done, so consumers slow down their fetch rate instead of pulling
+	// work they have to drop. Release must be called when the work is done.
+	Admit(ctx context.Context) (release func(), err error)
+	// WaitUntilBelow blocks until memory budget utilization falls below the level
+	// (for example, 0.8 means 80%) or the context is done.
+	WaitUntilBelow(ctx context.Context, level float64) error
 	// Quit terminates service gracefully.
 	Quit()
 }
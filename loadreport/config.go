@@ -0,0 +1,33 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package loadreport
+
+import (
+	"errors"
+
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+)
+
+// Config - ORCA reporting configuration.
+type Config struct {
+	// MinReportingInterval - lower bound of the out-of-band reporting interval.
+	// Zero means gRPC default value (30s).
+	MinReportingInterval duration.Duration `json:"min_reporting_interval"`
+	// ApplicationUtilization - report memory budget utilization as application utilization.
+	// Weighted round robin balancer prefers application utilization to CPU utilization,
+	// so this makes clients balance traffic according to memory pressure.
+	ApplicationUtilization bool `json:"application_utilization"`
+}
+
+// Prepare - config validator.
+func (c *Config) Prepare() error {
+	if c.MinReportingInterval.Duration < 0 {
+		return errors.New("negative MinReportingInterval")
+	}
+
+	return nil
+}
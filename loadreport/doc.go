@@ -0,0 +1,10 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+// Package loadreport publishes memory pressure as ORCA (Open Request Cost Aggregation) backend metrics,
+// so that gRPC clients using weighted round robin balancing route traffic away from the instances
+// running out of memory before they have to shed requests.
+package loadreport
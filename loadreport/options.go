@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package loadreport
+
+import (
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"google.golang.org/grpc/orca"
+)
+
+// Option - reporter constructor options.
+type Option interface {
+	anchor()
+}
+
+type serverMetricsProviderOption struct {
+	val orca.ServerMetricsProvider
+}
+
+func (o serverMetricsProviderOption) anchor() {}
+
+// WithServerMetricsProvider provides application metrics (like CPU utilization or QPS)
+// that are reported along with the memory pressure metrics.
+func WithServerMetricsProvider(provider orca.ServerMetricsProvider) Option {
+	return &serverMetricsProviderOption{val: provider}
+}
+
+type operatorOption struct {
+	val backpressure.Operator
+}
+
+func (o operatorOption) anchor() {}
+
+// WithOperator provides the operator applying throttling, so that the share of throttled requests
+// is reported as it's actually applied (rather than requested by controller).
+func WithOperator(operator backpressure.Operator) Option {
+	return &operatorOption{val: operator}
+}
+
+type shadowModeOption struct{}
+
+func (o shadowModeOption) anchor() {}
+
+// WithShadowMode makes reporter run in the shadow (dry-run) mode: memory pressure is not reported,
+// so that clients don't change the load balancing.
+func WithShadowMode() Option {
+	return &shadowModeOption{}
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package loadreport
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync/atomic"
+
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/orca"
+)
+
+const (
+	// UtilizationThrottling - name of the utilization metric reporting the share of throttled requests.
+	UtilizationThrottling = "memlimiter.throttling"
+	// UtilizationRSS - name of the utilization metric reporting RSS related to RSS limit.
+	UtilizationRSS = "memlimiter.rss"
+	// MetricRSSBytes - name of the per-call metric reporting RSS in bytes.
+	MetricRSSBytes = "memlimiter.rss_bytes"
+	// MetricUtilization - name of the per-call metric reporting memory budget utilization
+	// (unlike memory utilization, it may exceed 1).
+	MetricUtilization = "memlimiter.utilization"
+)
+
+// percents is a constant for converting throttling percentage to share.
+const percents = 100
+
+// unset is the value of the unset ORCA metrics.
+const unset = -1
+
+// Reporter is an Operator that publishes memory pressure as ORCA backend metrics:
+//   - memory utilization is memory budget utilization (capped with 1);
+//   - utilization metrics "memlimiter.throttling" and "memlimiter.rss" are the share of throttled requests
+//     applied by the operator provided with WithOperator (not reported without it) and RSS related to RSS limit;
+//   - per-call metrics "memlimiter.rss_bytes" and "memlimiter.utilization" are RSS and uncapped
+//     memory budget utilization.
+//
+// Metrics are sent both per-call in trailers and out-of-band; in the shadow mode only application metrics
+// are reported. The reporter is supposed to be combined with the default operator:
+//
+//	operator := backpressure.NewOperator(logger)
+//	reporter, err := loadreport.NewReporter(cfg, loadreport.WithOperator(operator))
+//	operator = backpressure.NewCompositeOperator(operator, reporter)
+//	server := grpc.NewServer(reporter.ServerOption())
+//	err = reporter.Register(server)
+type Reporter interface {
+	backpressure.Operator
+	orca.ServerMetricsProvider
+	// ServerOption returns server option enabling per-call metrics reporting in trailers.
+	ServerOption() grpc.ServerOption
+	// Register registers ORCA out-of-band reporting service on the server.
+	Register(registrar grpc.ServiceRegistrar) error
+}
+
+var _ Reporter = (*reporterImpl)(nil)
+
+// reporterImpl is the implementation of the Reporter interface.
+type reporterImpl struct {
+	cfg *Config
+	// base provides application metrics; may be nil.
+	base orca.ServerMetricsProvider
+	// operator provides the applied throttling percentage; may be nil.
+	operator backpressure.Operator
+	// shadow is true if reporter runs in the shadow (dry-run) mode: memory pressure is not reported.
+	shadow bool
+	// metrics are the latest memory pressure metrics.
+	metrics atomic.Pointer[orca.ServerMetrics]
+}
+
+// NewReporter constructs a new Reporter.
+func NewReporter(cfg *Config, options ...Option) (Reporter, error) {
+	if cfg == nil {
+		return nil, errors.New("nil config")
+	}
+
+	if err := cfg.Prepare(); err != nil {
+		return nil, fmt.Errorf("prepare config: %w", err)
+	}
+
+	out := &reporterImpl{cfg: cfg}
+
+	for _, op := range options {
+		//nolint:gocritic
+		switch t := op.(type) {
+		case *serverMetricsProviderOption:
+			out.base = t.val
+		case *operatorOption:
+			out.operator = t.val
+		case *shadowModeOption:
+			out.shadow = true
+		}
+	}
+
+	return out, nil
+}
+
+// SetControlParameters updates memory pressure metrics.
+func (r *reporterImpl) SetControlParameters(value *stats.ControlParameters) error {
+	if r.shadow || value == nil || value.ControllerStats == nil || value.ControllerStats.MemoryBudget == nil {
+		return nil
+	}
+
+	budget := value.ControllerStats.MemoryBudget
+
+	metrics := &orca.ServerMetrics{
+		CPUUtilization: unset,
+		MemUtilization: clamp(budget.Utilization),
+		AppUtilization: unset,
+		QPS:            unset,
+		EPS:            unset,
+		Utilization:    map[string]float64{},
+		RequestCost:    map[string]float64{},
+		NamedMetrics: map[string]float64{
+			MetricRSSBytes:    float64(budget.RSSActual),
+			MetricUtilization: max(budget.Utilization, 0),
+		},
+	}
+
+	if budget.RSSLimit > 0 {
+		metrics.Utilization[UtilizationRSS] = clamp(float64(budget.RSSActual) / float64(budget.RSSLimit))
+	}
+
+	if r.cfg.ApplicationUtilization {
+		metrics.AppUtilization = max(budget.Utilization, 0)
+	}
+
+	r.metrics.Store(metrics)
+
+	return nil
+}
+
+// ServerMetrics returns the actual metrics merged with the application ones.
+func (r *reporterImpl) ServerMetrics() *orca.ServerMetrics {
+	out := &orca.ServerMetrics{
+		CPUUtilization: unset,
+		MemUtilization: unset,
+		AppUtilization: unset,
+		QPS:            unset,
+		EPS:            unset,
+		Utilization:    map[string]float64{},
+		RequestCost:    map[string]float64{},
+		NamedMetrics:   map[string]float64{},
+	}
+
+	if r.base != nil {
+		if base := r.base.ServerMetrics(); base != nil {
+			out.CPUUtilization = base.CPUUtilization
+			out.MemUtilization = base.MemUtilization
+			out.AppUtilization = base.AppUtilization
+			out.QPS = base.QPS
+			out.EPS = base.EPS
+			maps.Copy(out.Utilization, base.Utilization)
+			maps.Copy(out.RequestCost, base.RequestCost)
+			maps.Copy(out.NamedMetrics, base.NamedMetrics)
+		}
+	}
+
+	metrics := r.metrics.Load()
+	if metrics == nil {
+		return out
+	}
+
+	out.MemUtilization = metrics.MemUtilization
+
+	if metrics.AppUtilization != unset {
+		out.AppUtilization = metrics.AppUtilization
+	}
+
+	maps.Copy(out.Utilization, metrics.Utilization)
+	maps.Copy(out.NamedMetrics, metrics.NamedMetrics)
+
+	// Throttling is taken at the moment of reporting, since operator may apply control parameters
+	// after the reporter within the composite operator.
+	if r.operator != nil {
+		pressure := backpressure.GetPressure(r.operator)
+		out.Utilization[UtilizationThrottling] = clamp(float64(pressure.ThrottlingPercentage) / percents)
+	}
+
+	return out
+}
+
+// ServerOption returns server option enabling per-call metrics reporting.
+func (r *reporterImpl) ServerOption() grpc.ServerOption {
+	return orca.CallMetricsServerOption(r)
+}
+
+// Register registers ORCA out-of-band reporting service.
+func (r *reporterImpl) Register(registrar grpc.ServiceRegistrar) error {
+	err := orca.Register(registrar, orca.ServiceOptions{
+		ServerMetricsProvider: r,
+		MinReportingInterval:  r.cfg.MinReportingInterval.Duration,
+	})
+	if err != nil {
+		return fmt.Errorf("register ORCA service: %w", err)
+	}
+
+	return nil
+}
+
+// AllowRequest always allows requests, because reporter doesn't throttle anything.
+func (r *reporterImpl) AllowRequest() bool { return true }
+
+// GetStats returns no statistics, since all the reported values are already available in MemLimiter statistics.
+func (r *reporterImpl) GetStats() (*stats.BackpressureStats, error) {
+	return &stats.BackpressureStats{}, nil
+}
+
+// Quit does nothing, since reporter has no background activity.
+func (r *reporterImpl) Quit() {}
+
+// clamp makes value belong to [0; 1].
+func clamp(value float64) float64 {
+	return min(max(value, 0), 1)
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package loadreport
+
+import (
+	"testing"
+
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/orca"
+)
+
+type operatorStub struct {
+	backpressure.Operator
+
+	throttling uint32
+}
+
+func (o *operatorStub) Pressure() backpressure.Pressure {
+	return backpressure.Pressure{ThrottlingPercentage: o.throttling}
+}
+
+func makeControlParameters() *stats.ControlParameters {
+	return &stats.ControlParameters{
+		ThrottlingPercentage: 40,
+		ControllerStats: &stats.ControllerStats{
+			MemoryBudget: &stats.MemoryBudgetStats{
+				Utilization: 1.2,
+				RSSActual:   900,
+				RSSLimit:    1000,
+			},
+		},
+	}
+}
+
+func TestReporter(t *testing.T) {
+	base := orca.NewServerMetricsRecorder()
+	base.SetCPUUtilization(0.3)
+	base.SetQPS(10)
+
+	// The actuator has applied less throttling than requested.
+	operator := &operatorStub{throttling: 20}
+
+	reporter, err := NewReporter(
+		&Config{ApplicationUtilization: true},
+		WithServerMetricsProvider(base),
+		WithOperator(operator),
+	)
+	require.NoError(t, err)
+
+	// No metrics until the first control parameters.
+	metrics := reporter.ServerMetrics()
+	require.InDelta(t, -1, metrics.MemUtilization, 0)
+	require.InDelta(t, 0.3, metrics.CPUUtilization, 0)
+
+	require.NoError(t, reporter.SetControlParameters(makeControlParameters()))
+
+	metrics = reporter.ServerMetrics()
+	require.InDelta(t, 1, metrics.MemUtilization, 0)
+	require.InDelta(t, 1.2, metrics.AppUtilization, 1e-9)
+	require.InDelta(t, 0.3, metrics.CPUUtilization, 0)
+	require.InDelta(t, 10, metrics.QPS, 0)
+	require.InDelta(t, 0.2, metrics.Utilization[UtilizationThrottling], 1e-9)
+	require.InDelta(t, 0.9, metrics.Utilization[UtilizationRSS], 1e-9)
+	require.InDelta(t, 900, metrics.NamedMetrics[MetricRSSBytes], 0)
+	require.InDelta(t, 1.2, metrics.NamedMetrics[MetricUtilization], 1e-9)
+
+	server := grpc.NewServer(reporter.ServerOption())
+	defer server.Stop()
+
+	require.NoError(t, reporter.Register(server))
+	require.Contains(t, server.GetServiceInfo(), "xds.service.orca.v3.OpenRcaService")
+}
+
+func TestReporterShadowMode(t *testing.T) {
+	base := orca.NewServerMetricsRecorder()
+	base.SetCPUUtilization(0.3)
+
+	reporter, err := NewReporter(
+		&Config{ApplicationUtilization: true},
+		WithServerMetricsProvider(base),
+		WithOperator(&operatorStub{throttling: 20}),
+		WithShadowMode(),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, reporter.SetControlParameters(makeControlParameters()))
+
+	// Only application metrics are reported.
+	metrics := reporter.ServerMetrics()
+	require.InDelta(t, -1, metrics.MemUtilization, 0)
+	require.InDelta(t, -1, metrics.AppUtilization, 0)
+	require.InDelta(t, 0.3, metrics.CPUUtilization, 0)
+	require.Empty(t, metrics.Utilization)
+	require.Empty(t, metrics.NamedMetrics)
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// namespaceRegexp - valid metric name prefix.
+	namespaceRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	// labelNameRegexp - valid label name.
+	labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// reservedLabels are the label names used by the metrics themselves.
+var reservedLabels = []string{labelZone, labelWindow, labelKind, labelConsumer, labelActuator, labelComponent}
+
+// Config - metrics exposition configuration.
+type Config struct {
+	// Namespace - optional prefix of metric names, e.g. "myservice" turns "memlimiter_utilization"
+	// into "myservice_memlimiter_utilization".
+	Namespace string `json:"namespace"`
+	// ConstLabels - labels added to every sample, e.g. {"instance_group": "blue"}.
+	ConstLabels map[string]string `json:"const_labels"`
+}
+
+// Prepare - config validator.
+func (c *Config) Prepare() error {
+	if c.Namespace != "" && !namespaceRegexp.MatchString(c.Namespace) {
+		return fmt.Errorf("invalid Namespace value '%s'", c.Namespace)
+	}
+
+	for name := range c.ConstLabels {
+		if !labelNameRegexp.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid ConstLabels name '%s'", name)
+		}
+
+		if slices.Contains(reservedLabels, name) {
+			return fmt.Errorf("ConstLabels name '%s' is reserved", name)
+		}
+	}
+
+	return nil
+}
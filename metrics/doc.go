@@ -0,0 +1,9 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+// Package metrics exposes MemLimiter statistics in the Prometheus text exposition format
+// without any dependencies on the Prometheus client libraries.
+package metrics
@@ -0,0 +1,371 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+// ContentType - content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Source provides MemLimiter statistics (memlimiter.Service implements it).
+type Source interface {
+	GetStats() (*stats.MemLimiterStats, error)
+}
+
+// NewHandler returns http.Handler serving MemLimiter statistics in the Prometheus text exposition format.
+// The list of metrics is documented in README.
+func NewHandler(source Source, cfg *Config) (http.Handler, error) {
+	if source == nil {
+		return nil, errors.New("nil source")
+	}
+
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	if err := cfg.Prepare(); err != nil {
+		return nil, fmt.Errorf("prepare config: %w", err)
+	}
+
+	return &handler{source: source, cfg: cfg}, nil
+}
+
+// handler serves MemLimiter statistics.
+type handler struct {
+	source Source
+	cfg    *Config
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	memLimiterStats, err := h.source.GetStats()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("get stats: %v", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	var buf bytes.Buffer
+
+	if err := Write(&buf, memLimiterStats, h.cfg); err != nil {
+		http.Error(w, fmt.Sprintf("write metrics: %v", err), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	_, _ = buf.WriteTo(w)
+}
+
+// Write writes statistics in the Prometheus text exposition format. Nil statistics produce empty output.
+func Write(w io.Writer, memLimiterStats *stats.MemLimiterStats, cfg *Config) error {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	c := newCollector(cfg)
+	c.collect(memLimiterStats)
+
+	for _, f := range c.families {
+		if _, err := io.WriteString(w, c.format(f)); err != nil {
+			return fmt.Errorf("write family %s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+// metric types.
+const (
+	gauge   = "gauge"
+	counter = "counter"
+)
+
+// label names.
+const (
+	labelZone      = "zone"
+	labelWindow    = "window"
+	labelKind      = "kind"
+	labelConsumer  = "consumer"
+	labelActuator  = "actuator"
+	labelComponent = "component"
+)
+
+// label is a label name-value pair.
+type label struct {
+	name, value string
+}
+
+// sample is a single metric value.
+type sample struct {
+	labels []label
+	value  float64
+}
+
+// family is a group of samples sharing the same name.
+type family struct {
+	name    string
+	help    string
+	kind    string
+	samples []sample
+}
+
+// collector converts statistics to metric families.
+type collector struct {
+	prefix      string
+	constLabels []label
+	families    []*family
+	index       map[string]*family
+}
+
+func newCollector(cfg *Config) *collector {
+	out := &collector{
+		index: make(map[string]*family),
+	}
+
+	if cfg.Namespace != "" {
+		out.prefix = cfg.Namespace + "_"
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.ConstLabels)) {
+		out.constLabels = append(out.constLabels, label{name: name, value: cfg.ConstLabels[name]})
+	}
+
+	return out
+}
+
+// add appends sample to the family, creating family if necessary.
+func (c *collector) add(name, kind, help string, value float64, labels ...label) {
+	f, ok := c.index[name]
+	if !ok {
+		f = &family{name: c.prefix + name, help: help, kind: kind}
+		c.index[name] = f
+		c.families = append(c.families, f)
+	}
+
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+//nolint:gocyclo,cyclop,funlen // Flat list of metrics is easier to maintain than a hierarchy of helpers.
+func (c *collector) collect(ms *stats.MemLimiterStats) {
+	if ms == nil {
+		return
+	}
+
+	if ms.Controller != nil {
+		c.collectController(ms.Controller)
+	}
+
+	if bp := ms.Backpressure; bp != nil {
+		if cp := bp.ControlParameters; cp != nil {
+			c.add("memlimiter_gogc", gauge, "GOGC value requested by controller.", float64(cp.GOGC))
+			c.add("memlimiter_throttling_percentage", gauge,
+				"Percentage of requests requested by controller to be throttled.", float64(cp.ThrottlingPercentage))
+		}
+
+		if a := bp.Actuators; a != nil {
+			c.collectActuator("gogc", a.GOGC)
+			c.collectActuator("throttling", a.Throttling)
+		}
+
+		if t := bp.Throttling; t != nil {
+			c.add("memlimiter_requests_passed_total", counter, "Total number of requests allowed by backpressure operator.",
+				float64(t.Passed))
+			c.add("memlimiter_requests_throttled_total", counter,
+				"Total number of requests throttled by backpressure operator.", float64(t.Throttled))
+
+			for _, window := range t.Windows {
+				c.add("memlimiter_throttled_share", gauge, "Share of throttled requests within the rolling window.",
+					window.ThrottledShare, label{name: labelWindow, value: window.Window.String()})
+			}
+		}
+
+		if s := bp.Shrinking; s != nil {
+			for _, name := range slices.Sorted(maps.Keys(s.Components)) {
+				component := s.Components[name]
+				l := label{name: labelComponent, value: name}
+
+				c.add("memlimiter_shrink_calls_total", counter, "Total number of memory release calls.",
+					float64(component.Calls), l)
+				c.add("memlimiter_shrink_failures_total", counter, "Total number of memory release calls finished with error.",
+					float64(component.Failures), l)
+				c.add("memlimiter_shrink_timeouts_total", counter, "Total number of memory release calls that timed out.",
+					float64(component.Timeouts), l)
+				c.add("memlimiter_shrink_freed_bytes_total", counter, "Total amount of memory released on demand.",
+					float64(component.FreedBytes), l)
+			}
+		}
+
+		if s := bp.Cancellation; s != nil {
+			c.add("memlimiter_inflight_requests", gauge, "Number of requests being served.", float64(s.InFlight))
+			c.add("memlimiter_cancelled_requests_total", counter,
+				"Total number of in-flight requests cancelled due to critical memory pressure.", float64(s.Cancelled))
+		}
+
+		if s := bp.Connections; s != nil {
+			c.add("memlimiter_connections_open", gauge, "Number of open connections.", float64(s.Open))
+			c.add("memlimiter_connections_accepted_total", counter, "Total number of admitted connections.",
+				float64(s.Accepted))
+			c.add("memlimiter_connections_rejected_total", counter, "Total number of rejected connections.",
+				float64(s.Rejected))
+			c.add("memlimiter_connections_paused_total", counter, "Total number of times Accept has been paused.",
+				float64(s.Paused))
+			c.add("memlimiter_connections_pause_seconds_total", counter, "Total time Accept has been paused.",
+				s.PauseDuration.Seconds())
+		}
+
+		if s := bp.Health; s != nil {
+			c.add("memlimiter_health_serving", gauge, "Whether the service is reported as serving (1) or not (0).",
+				boolToFloat(s.Serving))
+			c.add("memlimiter_health_transitions_total", counter, "Total number of health status changes.",
+				float64(s.Transitions))
+		}
+
+		if s := bp.Shadow; s != nil {
+			c.add("memlimiter_shadow_gogc", gauge, "GOGC value that would have been applied in shadow mode.",
+				float64(s.GOGC))
+
+			for _, zone := range slices.Sorted(maps.Keys(s.ZoneDurations)) {
+				c.add("memlimiter_shadow_zone_seconds_total", counter,
+					"Total time spent in memory budget utilization zone in shadow mode.",
+					s.ZoneDurations[zone].Seconds(), label{name: labelZone, value: zone})
+			}
+		}
+	}
+
+	if s := ms.Admission; s != nil {
+		c.add("memlimiter_admission_admitted_total", counter, "Total number of units of work admitted by admission gate.",
+			float64(s.Admitted))
+		c.add("memlimiter_admission_throttled_total", counter, "Total number of refused admission attempts.",
+			float64(s.Throttled))
+		c.add("memlimiter_admission_waiting", gauge, "Number of callers waiting for admission.", float64(s.Waiting))
+		c.add("memlimiter_admission_in_flight", gauge, "Number of admitted units of work not released yet.",
+			float64(s.InFlight))
+	}
+}
+
+// collectController converts controller statistics.
+func (c *collector) collectController(cs *stats.ControllerStats) {
+	if mb := cs.MemoryBudget; mb != nil {
+		c.add("memlimiter_utilization", gauge, "Memory budget utilization ratio (1 means 100%).", mb.Utilization)
+
+		for _, zone := range []stats.Zone{stats.ZoneGreen, stats.ZoneGOGC, stats.ZoneThrottling, stats.ZoneCritical} {
+			c.add("memlimiter_zone", gauge, "Current memory budget utilization zone (1 for the current zone).",
+				boolToFloat(zone == mb.Zone), label{name: labelZone, value: zone.String()})
+		}
+
+		c.add("memlimiter_rss_bytes", gauge, "Physical memory (RSS) consumption.", float64(mb.RSSActual))
+		c.add("memlimiter_rss_limit_bytes", gauge, "Physical memory (RSS) consumption limit.", float64(mb.RSSLimit))
+		c.add("memlimiter_go_alloc_limit_bytes", gauge, "Allocation limit for Go runtime.", float64(mb.GoAllocLimit))
+
+		if sc := mb.SpecialConsumers; sc != nil {
+			c.collectConsumers("go", sc.Go)
+			c.collectConsumers("cgo", sc.Cgo)
+		}
+	}
+
+	if nextGC := cs.NextGC; nextGC != nil {
+		c.add("memlimiter_controller_p", gauge, "Proportional component output of the controller.", nextGC.P)
+		c.add("memlimiter_controller_output", gauge, "Final output of the controller.", nextGC.Output)
+	}
+}
+
+// collectConsumers converts special consumers statistics.
+func (c *collector) collectConsumers(kind string, consumers map[string]uint64) {
+	for _, name := range slices.Sorted(maps.Keys(consumers)) {
+		c.add("memlimiter_consumer_bytes", gauge, "Memory consumed by the special consumer.",
+			float64(consumers[name]), label{name: labelKind, value: kind}, label{name: labelConsumer, value: name})
+	}
+}
+
+// collectActuator converts actuator statistics.
+func (c *collector) collectActuator(name string, as *stats.ActuatorStats) {
+	if as == nil || !as.Enabled {
+		return
+	}
+
+	l := label{name: labelActuator, value: name}
+
+	c.add("memlimiter_actuator_value", gauge, "Control parameter value applied by actuator.", float64(as.Value), l)
+	c.add("memlimiter_actuator_updates_total", counter, "Total number of applied value changes.",
+		float64(as.Updates), l)
+	c.add("memlimiter_actuator_suppressed_total", counter, "Total number of changes ignored because of deadband.",
+		float64(as.Suppressed), l)
+}
+
+// format renders the family in the text exposition format.
+func (c *collector) format(f *family) string {
+	var sb strings.Builder
+
+	sb.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+	sb.WriteString("# TYPE " + f.name + " " + f.kind + "\n")
+
+	for _, s := range f.samples {
+		sb.WriteString(f.name)
+
+		labels := append(slices.Clone(c.constLabels), s.labels...)
+		if len(labels) > 0 {
+			sb.WriteByte('{')
+
+			for i, l := range labels {
+				if i > 0 {
+					sb.WriteByte(',')
+				}
+
+				sb.WriteString(l.name + `="` + escapeLabelValue(l.value) + `"`)
+			}
+
+			sb.WriteByte('}')
+		}
+
+		sb.WriteString(" " + formatValue(s.value) + "\n")
+	}
+
+	return sb.String()
+}
+
+var (
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string { return helpReplacer.Replace(s) }
+
+func escapeLabelValue(s string) string { return labelValueReplacer.Replace(s) }
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+func boolToFloat(v bool) float64 {
+	if v {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+)
+
+type sourceStub struct {
+	stats *stats.MemLimiterStats
+	err   error
+}
+
+func (s *sourceStub) GetStats() (*stats.MemLimiterStats, error) { return s.stats, s.err }
+
+func testStats() *stats.MemLimiterStats {
+	return &stats.MemLimiterStats{
+		Controller: &stats.ControllerStats{
+			MemoryBudget: &stats.MemoryBudgetStats{
+				SpecialConsumers: &stats.SpecialConsumersStats{
+					Cgo: map[string]uint64{"rocksdb": 1024},
+				},
+				RSSActual:    900,
+				RSSLimit:     1000,
+				GoAllocLimit: 800,
+				Utilization:  0.9,
+				Zone:         stats.ZoneThrottling,
+			},
+			NextGC: &stats.ControllerNextGCStats{P: 0.25, Output: 20},
+		},
+		Backpressure: &stats.BackpressureStats{
+			ControlParameters: &stats.ControlParameters{GOGC: 40, ThrottlingPercentage: 20},
+			Throttling: &stats.ThrottlingStats{
+				Windows:   []*stats.ThrottlingWindowStats{{Window: time.Minute, ThrottledShare: 0.5}},
+				Passed:    10,
+				Throttled: 5,
+				Total:     15,
+			},
+		},
+	}
+}
+
+func serve(t *testing.T, source Source, cfg *Config) *httptest.ResponseRecorder {
+	t.Helper()
+
+	h, err := NewHandler(source, cfg)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	return recorder
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("exposition", func(t *testing.T) {
+		recorder := serve(t, &sourceStub{stats: testStats()}, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+
+		body := recorder.Body.String()
+
+		for _, line := range []string{
+			"# HELP memlimiter_utilization Memory budget utilization ratio (1 means 100%).",
+			"# TYPE memlimiter_utilization gauge",
+			"memlimiter_utilization 0.9",
+			`memlimiter_zone{zone="green"} 0`,
+			`memlimiter_zone{zone="throttling"} 1`,
+			"memlimiter_rss_bytes 900",
+			"memlimiter_rss_limit_bytes 1000",
+			"memlimiter_go_alloc_limit_bytes 800",
+			`memlimiter_consumer_bytes{kind="cgo",consumer="rocksdb"} 1024`,
+			"memlimiter_controller_p 0.25",
+			"memlimiter_controller_output 20",
+			"memlimiter_gogc 40",
+			"memlimiter_throttling_percentage 20",
+			"# TYPE memlimiter_requests_throttled_total counter",
+			"memlimiter_requests_passed_total 10",
+			"memlimiter_requests_throttled_total 5",
+			`memlimiter_throttled_share{window="1m0s"} 0.5`,
+		} {
+			require.Contains(t, body, line+"\n")
+		}
+
+		require.NotContains(t, body, "memlimiter_admission")
+	})
+
+	t.Run("namespace and const labels", func(t *testing.T) {
+		recorder := serve(t, &sourceStub{stats: testStats()}, &Config{
+			Namespace:   "app",
+			ConstLabels: map[string]string{"pod": "a\"b", "dc": "x"},
+		})
+
+		body := recorder.Body.String()
+		require.Contains(t, body, "# TYPE app_memlimiter_utilization gauge\n")
+		require.Contains(t, body, `app_memlimiter_utilization{dc="x",pod="a\"b"} 0.9`+"\n")
+		require.Contains(t, body, `app_memlimiter_zone{dc="x",pod="a\"b",zone="critical"} 0`+"\n")
+
+		for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+			require.True(t, strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "app_"), line)
+		}
+	})
+
+	t.Run("no stats", func(t *testing.T) {
+		recorder := serve(t, &sourceStub{}, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Empty(t, recorder.Body.String())
+	})
+
+	t.Run("stats error", func(t *testing.T) {
+		recorder := serve(t, &sourceStub{err: errors.New("boom")}, nil)
+		require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
+
+func TestConfig(t *testing.T) {
+	require.NoError(t, (&Config{Namespace: "my_app", ConstLabels: map[string]string{"env": "prod"}}).Prepare())
+	require.Error(t, (&Config{Namespace: "1app"}).Prepare())
+	require.Error(t, (&Config{ConstLabels: map[string]string{"__name": "x"}}).Prepare())
+	require.Error(t, (&Config{ConstLabels: map[string]string{"my-label": "x"}}).Prepare())
+	require.Error(t, (&Config{ConstLabels: map[string]string{labelZone: "x"}}).Prepare())
+}
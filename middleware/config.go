@@ -0,0 +1,310 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"text/template"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// defaultClientThrottlingK is the multiplier recommended by Google SRE book.
+	defaultClientThrottlingK = 2
+	// defaultClientThrottlingWindow is the history length recommended by Google SRE book.
+	defaultClientThrottlingWindow = 2 * time.Minute
+	// defaultStreamRetryInterval is the default interval between admission attempts in the delay mode.
+	defaultStreamRetryInterval = 100 * time.Millisecond
+	// defaultStreamMaxDelay is the default message delay limit in the delay mode.
+	defaultStreamMaxDelay = 5 * time.Second
+	// defaultHTTPRetryAfterMin is the default Retry-After value for the lowest pressure.
+	defaultHTTPRetryAfterMin = time.Second
+	// defaultHTTPRetryAfterMax is the default Retry-After value for the highest pressure.
+	defaultHTTPRetryAfterMax = 30 * time.Second
+	// defaultThrottleLoggingSummaryInterval is the default interval of throttled requests summaries.
+	defaultThrottleLoggingSummaryInterval = 10 * time.Second
+)
+
+// StreamMode - the way of throttling individual stream messages.
+type StreamMode string
+
+const (
+	// StreamModeReject makes stream fail with ResourceExhausted code as soon as a message is throttled.
+	StreamModeReject StreamMode = "reject"
+	// StreamModeDelay makes stream wait before receiving a message until the message is admitted
+	// or the delay limit is exceeded. While the message is not received, gRPC flow control
+	// slows down the sender.
+	StreamModeDelay StreamMode = "delay"
+)
+
+// Config - middleware configuration.
+type Config struct {
+	// GRPCClient - client-side adaptive throttling configuration for gRPC client interceptors.
+	// Defaults are used if the section is empty.
+	GRPCClient *ClientThrottlingConfig `json:"grpc_client"`
+	// GRPCServer - server-side gRPC interceptors and tap handle configuration.
+	GRPCServer *ServerConfig `json:"grpc_server"`
+	// GRPCStream - per-message admission for the server-side streams.
+	// If empty, admission is performed only once, when the stream is opened.
+	GRPCStream *StreamThrottlingConfig `json:"grpc_stream"`
+	// HTTP - net/http middleware configuration. Defaults are used if the section is empty.
+	HTTP *HTTPConfig `json:"http"`
+	// ThrottleLogging - logging of the throttled requests. Defaults are used if the section is empty.
+	ThrottleLogging *ThrottleLoggingConfig `json:"throttle_logging"`
+}
+
+// ClientThrottlingConfig - client-side adaptive throttling configuration
+// (see https://sre.google/sre-book/handling-overload/#client-side-throttling-a7sYUg).
+type ClientThrottlingConfig struct {
+	// K - multiplier of the accepted requests number. The client starts rejecting requests locally
+	// when the number of requests exceeds K times the number of accepts.
+	// Lower values make the client more aggressive. Zero means default value (2).
+	K float64 `json:"k"`
+	// Window - the period of history taken into account. Zero means default value (2m).
+	Window duration.Duration `json:"window"`
+}
+
+// Prepare - config validator.
+func (c *ClientThrottlingConfig) Prepare() error {
+	c.applyDefaults()
+
+	if c.K < 1 {
+		return errors.New("invalid K value (must be greater than or equal to 1)")
+	}
+
+	if c.Window.Duration < time.Second {
+		return errors.New("invalid Window value (must be greater than or equal to 1s)")
+	}
+
+	return nil
+}
+
+func (c *ClientThrottlingConfig) applyDefaults() {
+	if c.K == 0 {
+		c.K = defaultClientThrottlingK
+	}
+
+	if c.Window.Duration == 0 {
+		c.Window.Duration = defaultClientThrottlingWindow
+	}
+}
+
+// ServerConfig - server-side gRPC interceptors and tap handle configuration.
+type ServerConfig struct {
+	// ExemptMethods - methods that are never throttled (like health checks).
+	// Patterns are matched against the full method name with path.Match, e.g. "/grpc.health.v1.Health/*".
+	ExemptMethods []string `json:"exempt_methods"`
+	// Rejection - the way of rejecting throttled requests. If empty, requests are rejected
+	// with ResourceExhausted code and the default message.
+	Rejection *RejectionConfig `json:"rejection"`
+}
+
+// Prepare - config validator.
+func (c *ServerConfig) Prepare() error {
+	for _, pattern := range c.ExemptMethods {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid ExemptMethods pattern '%s': %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// RejectionConfig - rejection policy for the throttled gRPC requests and stream messages.
+type RejectionConfig struct {
+	// Code - status code of the rejected requests, e.g. "RESOURCE_EXHAUSTED" or "UNAVAILABLE".
+	// Empty value means ResourceExhausted. Keep in mind that client-side throttling
+	// (see MakeUnaryClientInterceptor) recognizes ResourceExhausted code only.
+	Code codes.Code `json:"code"`
+	// Methods - per-method status codes overriding Code. The first matching pattern wins.
+	Methods []*MethodRejectionConfig `json:"methods"`
+	// Message - text/template of the status message. Available fields are the ones of RejectionInfo:
+	// {{.Method}}, {{.Code}}, {{.Zone}}, {{.Utilization}} and {{.ThrottlingPercentage}}.
+	// Empty value means default message.
+	Message string `json:"message"`
+	// Details - attach google.rpc.ErrorInfo with the current memory budget utilization zone,
+	// utilization and throttling percentage to the status.
+	Details bool `json:"details"`
+}
+
+// Prepare - config validator.
+func (c *RejectionConfig) Prepare() error {
+	if c.Code == codes.OK {
+		c.Code = codes.ResourceExhausted
+	}
+
+	if err := validateRejectionCode(c.Code); err != nil {
+		return fmt.Errorf("invalid Code value: %w", err)
+	}
+
+	for i, methodCfg := range c.Methods {
+		if methodCfg == nil {
+			return fmt.Errorf("empty Methods[%d]", i)
+		}
+
+		if err := methodCfg.Prepare(); err != nil {
+			return fmt.Errorf("invalid Methods[%d]: %w", i, err)
+		}
+	}
+
+	if _, err := template.New("message").Parse(c.Message); err != nil {
+		return fmt.Errorf("invalid Message template: %w", err)
+	}
+
+	return nil
+}
+
+// MethodRejectionConfig - status code of the rejected requests for particular methods.
+type MethodRejectionConfig struct {
+	// Pattern - path.Match pattern matched against the full method name, e.g. "/my.Service/*".
+	Pattern string `json:"pattern"`
+	// Code - status code of the rejected requests.
+	Code codes.Code `json:"code"`
+}
+
+// Prepare - config validator.
+func (c *MethodRejectionConfig) Prepare() error {
+	if _, err := path.Match(c.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid Pattern '%s': %w", c.Pattern, err)
+	}
+
+	if err := validateRejectionCode(c.Code); err != nil {
+		return fmt.Errorf("invalid Code value: %w", err)
+	}
+
+	return nil
+}
+
+// validateRejectionCode checks that code denotes an error.
+func validateRejectionCode(code codes.Code) error {
+	if code == codes.OK || code > codes.Unauthenticated {
+		return fmt.Errorf("'%s' is not an error code", code)
+	}
+
+	return nil
+}
+
+// StreamThrottlingConfig - per-message admission configuration for the server-side gRPC streams.
+type StreamThrottlingConfig struct {
+	// Mode - throttling mode: "reject" or "delay". Empty value means "reject".
+	Mode StreamMode `json:"mode"`
+	// RetryInterval - interval between admission attempts in the delay mode. Zero means default value (100ms).
+	RetryInterval duration.Duration `json:"retry_interval"`
+	// MaxDelay - maximal delay of a single message in the delay mode;
+	// when it's exceeded, stream is rejected. Zero means default value (5s).
+	MaxDelay duration.Duration `json:"max_delay"`
+}
+
+// Prepare - config validator.
+func (c *StreamThrottlingConfig) Prepare() error {
+	if c.Mode == "" {
+		c.Mode = StreamModeReject
+	}
+
+	if c.Mode != StreamModeReject && c.Mode != StreamModeDelay {
+		return fmt.Errorf("invalid Mode value '%s' (must be '%s' or '%s')", c.Mode, StreamModeReject, StreamModeDelay)
+	}
+
+	if c.RetryInterval.Duration < 0 || c.MaxDelay.Duration < 0 {
+		return errors.New("negative RetryInterval or MaxDelay")
+	}
+
+	if c.RetryInterval.Duration == 0 {
+		c.RetryInterval.Duration = defaultStreamRetryInterval
+	}
+
+	if c.MaxDelay.Duration == 0 {
+		c.MaxDelay.Duration = defaultStreamMaxDelay
+	}
+
+	return nil
+}
+
+// HTTPConfig - net/http middleware configuration.
+type HTTPConfig struct {
+	// StatusCode - status code of the throttled requests: 503 (Service Unavailable)
+	// or 429 (Too Many Requests). Zero means default value (503).
+	StatusCode int `json:"status_code"`
+	// RetryAfterMin - Retry-After header value corresponding to the lowest pressure.
+	// Zero means default value (1s).
+	RetryAfterMin duration.Duration `json:"retry_after_min"`
+	// RetryAfterMax - Retry-After header value corresponding to the highest pressure
+	// (when all the requests are throttled). Zero means default value (30s).
+	RetryAfterMax duration.Duration `json:"retry_after_max"`
+	// ExemptRoutes - routes that are never throttled (like health checks).
+	// Patterns are matched against the route with path.Match, e.g. "/healthz" or "/debug/*".
+	ExemptRoutes []string `json:"exempt_routes"`
+}
+
+// Prepare - config validator.
+func (c *HTTPConfig) Prepare() error {
+	c.applyDefaults()
+
+	if c.StatusCode != http.StatusServiceUnavailable && c.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("invalid StatusCode value %d (must be 503 or 429)", c.StatusCode)
+	}
+
+	if c.RetryAfterMin.Duration < time.Second || c.RetryAfterMax.Duration < c.RetryAfterMin.Duration {
+		return errors.New("invalid RetryAfterMin or RetryAfterMax values (must satisfy 1s <= RetryAfterMin <= RetryAfterMax)")
+	}
+
+	for _, pattern := range c.ExemptRoutes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid ExemptRoutes pattern '%s': %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *HTTPConfig) applyDefaults() {
+	if c.StatusCode == 0 {
+		c.StatusCode = http.StatusServiceUnavailable
+	}
+
+	if c.RetryAfterMin.Duration == 0 {
+		c.RetryAfterMin.Duration = defaultHTTPRetryAfterMin
+	}
+
+	if c.RetryAfterMax.Duration == 0 {
+		c.RetryAfterMax.Duration = defaultHTTPRetryAfterMax
+	}
+}
+
+// ThrottleLoggingConfig - logging of the throttled requests. Instead of logging every throttled request,
+// middleware periodically logs summaries with the number of throttled requests per method (or route)
+// and optionally logs some of the throttled requests individually.
+type ThrottleLoggingConfig struct {
+	// SummaryInterval - interval of the summaries. Zero means default value (10s).
+	SummaryInterval duration.Duration `json:"summary_interval"`
+	// RequestRate - maximal number of individually logged throttled requests per second
+	// (for example, 0.1 means one request per 10 seconds). Zero disables individual logging.
+	RequestRate float64 `json:"request_rate"`
+}
+
+// Prepare - config validator.
+func (c *ThrottleLoggingConfig) Prepare() error {
+	if c.SummaryInterval.Duration < 0 || c.RequestRate < 0 {
+		return errors.New("negative SummaryInterval or RequestRate")
+	}
+
+	c.applyDefaults()
+
+	return nil
+}
+
+func (c *ThrottleLoggingConfig) applyDefaults() {
+	if c.SummaryInterval.Duration == 0 {
+		c.SummaryInterval.Duration = defaultThrottleLoggingSummaryInterval
+	}
+}
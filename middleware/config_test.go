@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientThrottlingConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		c := &ClientThrottlingConfig{}
+		require.NoError(t, c.Prepare())
+		require.InDelta(t, defaultClientThrottlingK, c.K, 0)
+		require.Equal(t, defaultClientThrottlingWindow, c.Window.Duration)
+	})
+
+	t.Run("invalid K", func(t *testing.T) {
+		c := &ClientThrottlingConfig{K: 0.5}
+		require.Error(t, c.Prepare())
+	})
+
+	t.Run("invalid window", func(t *testing.T) {
+		c := &ClientThrottlingConfig{Window: duration.Duration{Duration: time.Millisecond}}
+		require.Error(t, c.Prepare())
+	})
+}
+
+func TestStreamThrottlingConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		c := &StreamThrottlingConfig{}
+		require.NoError(t, c.Prepare())
+		require.Equal(t, StreamModeReject, c.Mode)
+		require.Equal(t, defaultStreamRetryInterval, c.RetryInterval.Duration)
+		require.Equal(t, defaultStreamMaxDelay, c.MaxDelay.Duration)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		c := &StreamThrottlingConfig{Mode: "drop"}
+		require.Error(t, c.Prepare())
+	})
+
+	t.Run("negative delay", func(t *testing.T) {
+		c := &StreamThrottlingConfig{MaxDelay: duration.Duration{Duration: -time.Second}}
+		require.Error(t, c.Prepare())
+	})
+}
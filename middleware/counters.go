@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"path"
+	"sync"
+	"sync/atomic"
+
+	"github.com/newcloudtechnologies/memlimiter/utils"
+)
+
+const (
+	// maxRegisteredNames is the maximal number of methods (or routes) counted separately.
+	maxRegisteredNames = 1000
+	// otherName is the name the requests are counted under when the number of names reaches the limit.
+	otherName = "<other>"
+)
+
+// methodCounters counts requests per method (e.g. the ones that would have been throttled in the shadow mode).
+// It is safe for concurrent use.
+type methodCounters struct {
+	// counters are per-method counters [string -> utils.Counter[uint64]].
+	counters sync.Map
+	// size is the number of methods counted.
+	size atomic.Int64
+}
+
+// register counts the request.
+func (r *methodCounters) register(method string) {
+	counter, ok := r.counters.Load(method)
+	if !ok {
+		counter = loadOrStoreLimited(&r.counters, &r.size, method, func() any { return utils.NewUint64Counter(nil) })
+	}
+
+	//nolint:forcetypeassert // Only utils.Counter[uint64] values are stored.
+	counter.(utils.Counter[uint64]).Inc(1)
+}
+
+// getStats returns the number of requests per method.
+func (r *methodCounters) getStats() map[string]uint64 {
+	out := make(map[string]uint64)
+
+	r.counters.Range(func(key, value any) bool {
+		//nolint:forcetypeassert // Only string keys and utils.Counter[uint64] values are stored.
+		out[key.(string)] = value.(utils.Counter[uint64]).Count()
+
+		return true
+	})
+
+	return out
+}
+
+// admissionCounters are the per-route (or per-method) admission counters.
+type admissionCounters struct {
+	requests  atomic.Uint64
+	throttled atomic.Uint64
+	exempted  atomic.Uint64
+}
+
+// admissionRegistry keeps admission counters per route (or per method).
+// It is safe for concurrent use.
+type admissionRegistry struct {
+	// counters are per-route counters [string -> *admissionCounters].
+	counters sync.Map
+	// size is the number of routes counted.
+	size atomic.Int64
+}
+
+// get returns counters for a particular route.
+func (r *admissionRegistry) get(name string) *admissionCounters {
+	counters, ok := r.counters.Load(name)
+	if !ok {
+		counters = loadOrStoreLimited(&r.counters, &r.size, name, func() any { return &admissionCounters{} })
+	}
+
+	//nolint:forcetypeassert // Only *admissionCounters values are stored.
+	return counters.(*admissionCounters)
+}
+
+// rangeCounters calls f for every route.
+func (r *admissionRegistry) rangeCounters(f func(name string, counters *admissionCounters)) {
+	r.counters.Range(func(key, value any) bool {
+		//nolint:forcetypeassert // Only string keys and *admissionCounters values are stored.
+		f(key.(string), value.(*admissionCounters))
+
+		return true
+	})
+}
+
+// loadOrStoreLimited returns the value stored under the name, creating it if necessary.
+// Once the number of names reaches maxRegisteredNames, new names share the value stored under otherName,
+// so that the memory consumed by counters is bounded whatever names clients send.
+func loadOrStoreLimited(values *sync.Map, size *atomic.Int64, name string, create func() any) any {
+	if size.Load() >= maxRegisteredNames {
+		name = otherName
+
+		if value, ok := values.Load(name); ok {
+			return value
+		}
+	}
+
+	value, loaded := values.LoadOrStore(name, create())
+	if !loaded {
+		size.Add(1)
+	}
+
+	return value
+}
+
+// matchAny checks if name matches any of path.Match patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -8,31 +8,84 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 
 	"github.com/go-logr/logr"
 	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/tap"
+	"google.golang.org/protobuf/proto"
 )
 
 // GRPC provides server-side interceptors that must be used
-// at the time of GRPC server construction.
+// at the time of GRPC server construction, as well as client-side interceptors
+// that must be used at the time of GRPC client connection construction.
 type GRPC interface {
 	// MakeUnaryServerInterceptor returns unary server interceptor.
 	MakeUnaryServerInterceptor() grpc.UnaryServerInterceptor
 	// MakeStreamServerInterceptor returns stream server interceptor.
 	MakeStreamServerInterceptor() grpc.StreamServerInterceptor
+	// MakeTapHandle returns tap handle (see grpc.InTapHandle) that admits new streams before
+	// any message is received and decoded. That's the cheapest place to shed load, since throttled
+	// requests don't cost memory for request messages. Tap handle applies the same method exemptions
+	// and counters as interceptors; requests admitted by tap handle are not admitted by interceptors again,
+	// so it's safe to install both.
+	MakeTapHandle() tap.ServerInHandle
+	// MakeUnaryClientInterceptor returns unary client interceptor implementing adaptive
+	// client-side throttling: when servers protected by MemLimiter reject requests with
+	// ResourceExhausted code, the client starts rejecting requests locally in proportion.
+	MakeUnaryClientInterceptor() grpc.UnaryClientInterceptor
+	// MakeStreamClientInterceptor returns stream client interceptor implementing adaptive
+	// client-side throttling.
+	MakeStreamClientInterceptor() grpc.StreamClientInterceptor
+	// SetServiceInfo provides the services registered in the server (see grpc.Server.GetServiceInfo).
+	// Tap handle sees the method names sent by clients before gRPC checks them, so once the services are known,
+	// requests to the other methods are counted under the single "<unknown>" method.
+	// It must be called after the services are registered.
+	SetServiceInfo(info map[string]grpc.ServiceInfo)
 }
 
 // grpcImpl is the implementation of the GRPC interface.
 type grpcImpl struct {
 	backpressureOperator backpressure.Operator
-	logger               logr.Logger
+	client               *grpcClient
+	// exemptMethods are path.Match patterns of the methods that are never throttled.
+	exemptMethods []string
+	// methods are per-method admission counters.
+	methods admissionRegistry
+	// knownMethods are the methods registered in the server.
+	knownMethods knownMethods
+	// streams is not nil if per-message stream admission is enabled.
+	streams *grpcStreams
+	// inFlight is not nil if in-flight requests cancellation is enabled.
+	inFlight backpressure.InFlightRegistry
+	// priority returns request priority for inFlight registry.
+	priority PriorityFunc
+	// cancelled counts requests cancelled by inFlight registry.
+	cancelled *methodCounters
+	// rejector builds errors for the throttled requests.
+	rejector *rejector
+	// shadow is not nil in the shadow mode.
+	shadow *methodCounters
+	// throttleLog logs the throttled requests.
+	throttleLog *throttleLogger
+	// streamThrottleLog logs the throttled stream messages.
+	streamThrottleLog *throttleLogger
+	logger            logr.Logger
 }
 
-// unknownGRPCMethod is a constant for the unknown GRPC method.
-const unknownGRPCMethod = "<unknown>"
+const (
+	// unknownGRPCMethod is a constant for the unknown GRPC method.
+	unknownGRPCMethod = "<unknown>"
+	// unknownGRPCTarget is a constant for the unknown GRPC target.
+	unknownGRPCTarget = "<unknown>"
+	// throttledMessage is the default status message of the throttled requests.
+	throttledMessage = "request has been throttled"
+)
 
 // MakeUnaryServerInterceptor returns a unary server interceptor.
 func (g *grpcImpl) MakeUnaryServerInterceptor() grpc.UnaryServerInterceptor {
@@ -42,19 +95,16 @@ func (g *grpcImpl) MakeUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (any, error) {
-		allowed := g.backpressureOperator.AllowRequest()
-		if allowed {
-			return handler(ctx, req)
-		}
+		method := g.grpcMethodFromUnaryInfo(info)
 
-		logger, err := logr.FromContext(ctx)
-		if err != nil {
-			logger = g.logger
+		allowed := g.admit(ctx, method)
+		if allowed {
+			return g.serveUnary(ctx, req, method, handler)
 		}
 
-		logger.Info("request has been throttled", "grpc_method", g.grpcMethodFromUnaryInfo(info))
+		g.throttleLog.log(ctx, method)
 
-		return nil, status.Error(codes.ResourceExhausted, "request has been throttled")
+		return nil, g.rejector.reject(ctx, method, throttledMessage)
 	}
 }
 
@@ -66,20 +116,222 @@ func (g *grpcImpl) MakeStreamServerInterceptor() grpc.StreamServerInterceptor {
 		info *grpc.StreamServerInfo,
 		handler grpc.StreamHandler,
 	) error {
-		allowed := g.backpressureOperator.AllowRequest()
+		method := g.grpcMethodFromStreamInfo(info)
+
+		allowed := g.admit(ss.Context(), method)
 		if allowed {
-			return handler(srv, ss)
+			return g.serveStream(srv, ss, method, handler)
 		}
 
-		logger, err := logr.FromContext(ss.Context())
-		if err != nil {
-			logger = g.logger
+		g.throttleLog.log(ss.Context(), method)
+
+		return g.rejector.reject(ss.Context(), method, throttledMessage)
+	}
+}
+
+// MakeTapHandle returns a tap handle.
+func (g *grpcImpl) MakeTapHandle() tap.ServerInHandle {
+	return func(ctx context.Context, info *tap.Info) (context.Context, error) {
+		method := unknownGRPCMethod
+		if info != nil && info.FullMethodName != "" {
+			method = g.knownMethods.resolve(info.FullMethodName)
+		}
+
+		if g.admit(ctx, method) {
+			return context.WithValue(ctx, tapAdmittedKey{}, struct{}{}), nil
 		}
 
-		logger.Info("request has been throttled", "grpc_method", g.grpcMethodFromStreamInfo(info))
+		g.throttleLog.log(ctx, method)
+
+		return nil, g.rejector.reject(ctx, method, throttledMessage)
+	}
+}
+
+// tapAdmittedKey marks contexts of the requests that have already been admitted by tap handle.
+type tapAdmittedKey struct{}
 
-		return status.Error(codes.ResourceExhausted, "request has been throttled")
+// admit makes admission decision and updates counters. Requests admitted by tap handle
+// are not admitted for the second time by interceptors.
+func (g *grpcImpl) admit(ctx context.Context, method string) bool {
+	if ctx.Value(tapAdmittedKey{}) != nil {
+		return true
 	}
+
+	counters := g.methods.get(method)
+	counters.requests.Add(1)
+
+	if matchAny(g.exemptMethods, method) {
+		counters.exempted.Add(1)
+
+		return true
+	}
+
+	if g.allowRequest(method) {
+		return true
+	}
+
+	counters.throttled.Add(1)
+
+	return false
+}
+
+// getServerStats returns per-method server-side statistics.
+func (g *grpcImpl) getServerStats() *stats.GRPCServerStats {
+	out := &stats.GRPCServerStats{
+		Methods: make(map[string]*stats.GRPCServerMethodStats),
+	}
+
+	g.methods.rangeCounters(func(method string, counters *admissionCounters) {
+		out.Methods[method] = &stats.GRPCServerMethodStats{
+			Requests:  counters.requests.Load(),
+			Throttled: counters.throttled.Load(),
+			Exempted:  counters.exempted.Load(),
+		}
+	})
+
+	return out
+}
+
+// serveUnary calls unary handler, registering request in the in-flight registry if necessary.
+// Exempt methods are never registered, so they're never cancelled.
+func (g *grpcImpl) serveUnary(ctx context.Context, req any, method string, handler grpc.UnaryHandler) (any, error) {
+	if g.inFlight == nil || matchAny(g.exemptMethods, method) {
+		return handler(ctx, req)
+	}
+
+	var size uint64
+	if msg, ok := req.(proto.Message); ok {
+		size = uint64(proto.Size(msg)) //nolint:gosec // Size is never negative.
+	}
+
+	request := g.inFlight.Track(ctx, g.priority(ctx, method), size)
+	defer request.Done()
+
+	resp, err := handler(request.Context(), req)
+	if cancelErr := g.checkCancelled(request, method); cancelErr != nil {
+		return nil, cancelErr
+	}
+
+	return resp, err
+}
+
+// serveStream calls stream handler, wrapping the stream if necessary.
+// Streams of exempt methods are passed as is.
+func (g *grpcImpl) serveStream(srv any, ss grpc.ServerStream, method string, handler grpc.StreamHandler) error {
+	if matchAny(g.exemptMethods, method) {
+		return handler(srv, ss)
+	}
+
+	var request *backpressure.InFlightRequest
+
+	if g.inFlight != nil {
+		request = g.inFlight.Track(ss.Context(), g.priority(ss.Context(), method), 0)
+		defer request.Done()
+
+		ss = &trackedStream{ServerStream: ss, request: request}
+	}
+
+	if g.streams != nil {
+		wrapped := g.streams.wrap(g, ss, method)
+
+		wrapped.counters.active.Add(1)
+		defer wrapped.counters.active.Add(-1)
+
+		ss = wrapped
+	}
+
+	err := handler(srv, ss)
+
+	if request != nil {
+		if cancelErr := g.checkCancelled(request, method); cancelErr != nil {
+			return cancelErr
+		}
+	}
+
+	return err
+}
+
+// checkCancelled returns distinct error if request has been cancelled by the in-flight registry.
+func (g *grpcImpl) checkCancelled(request *backpressure.InFlightRequest, method string) error {
+	if !errors.Is(context.Cause(request.Context()), backpressure.ErrRequestCancelled) {
+		return nil
+	}
+
+	g.cancelled.register(method)
+
+	logger, err := logr.FromContext(request.Context())
+	if err != nil {
+		logger = g.logger
+	}
+
+	logger.Info("request has been cancelled", "grpc_method", method)
+
+	return status.Error(codes.Unavailable, "request has been cancelled due to memory pressure")
+}
+
+// allowRequest asks backpressure operator for permission to execute request.
+func (g *grpcImpl) allowRequest(method string) bool {
+	return allowRequest(g.backpressureOperator, g.shadow, method)
+}
+
+// checkRequest asks backpressure operator for permission to proceed without registering the decision
+// in the throttling statistics (see backpressure.RequestChecker).
+func (g *grpcImpl) checkRequest(method string) bool {
+	return applyShadowMode(backpressure.CheckRequest(g.backpressureOperator), g.shadow, method)
+}
+
+// allowRequest asks backpressure operator for permission to execute request.
+// In the shadow mode (when shadow is not nil) requests are always allowed, but refusals are counted.
+func allowRequest(operator backpressure.Operator, shadow *methodCounters, name string) bool {
+	return applyShadowMode(operator.AllowRequest(), shadow, name)
+}
+
+// SetServiceInfo provides the services registered in the server.
+func (g *grpcImpl) SetServiceInfo(info map[string]grpc.ServiceInfo) { g.knownMethods.set(info) }
+
+// knownMethods is the set of the methods registered in the server. It is safe for concurrent use.
+type knownMethods struct {
+	// methods is nil until the services are known.
+	methods atomic.Pointer[map[string]struct{}]
+}
+
+// set registers the methods of the services.
+func (k *knownMethods) set(info map[string]grpc.ServiceInfo) {
+	methods := make(map[string]struct{})
+
+	for service, serviceInfo := range info {
+		for _, method := range serviceInfo.Methods {
+			methods["/"+service+"/"+method.Name] = struct{}{}
+		}
+	}
+
+	k.methods.Store(&methods)
+}
+
+// resolve returns unknownGRPCMethod if the method is not registered in the server.
+// Until the services are known, any method is returned as is.
+func (k *knownMethods) resolve(method string) string {
+	methods := k.methods.Load()
+	if methods == nil {
+		return method
+	}
+
+	if _, ok := (*methods)[method]; !ok {
+		return unknownGRPCMethod
+	}
+
+	return method
+}
+
+// applyShadowMode allows everything in the shadow mode (when shadow is not nil), but counts refusals.
+func applyShadowMode(allowed bool, shadow *methodCounters, name string) bool {
+	if !allowed && shadow != nil {
+		shadow.register(name)
+
+		return true
+	}
+
+	return allowed
 }
 
 // grpcMethodFromUnaryInfo returns the GRPC method from the unary server info.
@@ -93,7 +345,8 @@ func (g *grpcImpl) grpcMethodFromUnaryInfo(info *grpc.UnaryServerInfo) string {
 		return unknownGRPCMethod
 	}
 
-	return method
+	// Unknown service handler receives arbitrary methods as well.
+	return g.knownMethods.resolve(method)
 }
 
 // grpcMethodFromStreamInfo returns the GRPC method from the stream server info.
@@ -107,5 +360,27 @@ func (g *grpcImpl) grpcMethodFromStreamInfo(info *grpc.StreamServerInfo) string
 		return unknownGRPCMethod
 	}
 
-	return method
+	// Unknown service handler receives arbitrary methods as well.
+	return g.knownMethods.resolve(method)
+}
+
+// trackedStream replaces stream context with the one of the in-flight request
+// and accounts the size of the received messages.
+type trackedStream struct {
+	grpc.ServerStream
+	request *backpressure.InFlightRequest
+}
+
+func (s *trackedStream) Context() context.Context { return s.request.Context() }
+
+func (s *trackedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if msg, ok := m.(proto.Message); ok {
+		s.request.AddSize(uint64(proto.Size(msg))) //nolint:gosec // Size is never negative.
+	}
+
+	return nil
 }
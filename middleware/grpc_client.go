@@ -0,0 +1,269 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/newcloudtechnologies/memlimiter/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// clientThrottlingResolution is the bucket width of the client-side throttling history.
+const clientThrottlingResolution = time.Second
+
+// grpcClient keeps client-side adaptive throttling state for every target
+// the client interceptors were used with.
+// It is safe for concurrent use.
+type grpcClient struct {
+	// targets is the per-target throttling state [string -> *clientThrottler].
+	targets sync.Map
+	// requests is the total number of requests.
+	requests utils.Counter[uint64]
+	// accepted is the number of requests accepted by the servers.
+	accepted utils.Counter[uint64]
+	// rejectedLocally is the number of requests rejected without sending.
+	rejectedLocally utils.Counter[uint64]
+	// rejectedRemotely is the number of requests rejected by the servers.
+	rejectedRemotely utils.Counter[uint64]
+	// shadowRejected is the number of requests that would have been rejected locally in the shadow mode.
+	shadowRejected utils.Counter[uint64]
+	// shadow is set in the shadow mode: requests are never rejected locally.
+	shadow bool
+	// cfg is the client-side throttling configuration.
+	cfg *ClientThrottlingConfig
+}
+
+// clientThrottler implements adaptive throttling for a single target.
+// The client rejects requests locally with probability
+//
+//	max(0, (requests - K*accepts) / (requests + 1))
+//
+// where requests and accepts are counted within the configured window.
+type clientThrottler struct {
+	// requests is the number of requests issued within the window.
+	requests *utils.RollingCounter
+	// accepts is the number of requests accepted by the server within the window.
+	accepts *utils.RollingCounter
+	// k is the multiplier of accepts.
+	k float64
+	// window is the history length.
+	window time.Duration
+}
+
+// newGRPCClient creates a new client-side throttling state holder.
+func newGRPCClient(cfg *ClientThrottlingConfig, shadow bool) *grpcClient {
+	requests := utils.NewUint64Counter(nil)
+
+	return &grpcClient{
+		requests:         requests,
+		accepted:         utils.NewUint64Counter(requests),
+		rejectedLocally:  utils.NewUint64Counter(requests),
+		rejectedRemotely: utils.NewUint64Counter(requests),
+		shadowRejected:   utils.NewUint64Counter(nil),
+		shadow:           shadow,
+		cfg:              cfg,
+	}
+}
+
+// throttler returns the throttler corresponding to the given target.
+func (c *grpcClient) throttler(target string) *clientThrottler {
+	if val, ok := c.targets.Load(target); ok {
+		//nolint:forcetypeassert // Only *clientThrottler values are stored.
+		return val.(*clientThrottler)
+	}
+
+	th := &clientThrottler{
+		requests: utils.NewRollingCounter(c.cfg.Window.Duration, clientThrottlingResolution),
+		accepts:  utils.NewRollingCounter(c.cfg.Window.Duration, clientThrottlingResolution),
+		k:        c.cfg.K,
+		window:   c.cfg.Window.Duration,
+	}
+
+	val, _ := c.targets.LoadOrStore(target, th)
+
+	//nolint:forcetypeassert // Only *clientThrottler values are stored.
+	return val.(*clientThrottler)
+}
+
+// allow decides whether the request can be sent to the target.
+// In the shadow mode requests are always allowed, but would-be rejections are counted.
+func (c *grpcClient) allow(th *clientThrottler) bool {
+	probability := th.rejectionProbability()
+
+	th.requests.Inc(1)
+
+	//nolint:gosec // Non-cryptographic RNG is intentional for probabilistic throttling decisions.
+	if probability > 0 && rand.Float64() < probability {
+		if c.shadow {
+			c.shadowRejected.Inc(1)
+
+			return true
+		}
+
+		c.rejectedLocally.Inc(1)
+
+		return false
+	}
+
+	return true
+}
+
+// report registers the outcome of the request that has been sent to the target.
+func (c *grpcClient) report(th *clientThrottler, err error) {
+	// Only the explicit rejections are considered as the signs of the server overload;
+	// any other outcome means that the server has accepted the request.
+	if status.Code(err) == codes.ResourceExhausted {
+		c.rejectedRemotely.Inc(1)
+
+		return
+	}
+
+	th.accepts.Inc(1)
+	c.accepted.Inc(1)
+}
+
+// getStats returns client-side throttling statistics.
+func (c *grpcClient) getStats() *stats.GRPCClientStats {
+	out := &stats.GRPCClientStats{
+		Targets:               make(map[string]*stats.GRPCClientTargetStats),
+		Requests:              c.requests.Count(),
+		Accepted:              c.accepted.Count(),
+		RejectedLocally:       c.rejectedLocally.Count(),
+		RejectedRemotely:      c.rejectedRemotely.Count(),
+		ShadowRejectedLocally: c.shadowRejected.Count(),
+	}
+
+	c.targets.Range(func(key, value any) bool {
+		//nolint:forcetypeassert // Only string keys and *clientThrottler values are stored.
+		target, th := key.(string), value.(*clientThrottler)
+
+		out.Targets[target] = &stats.GRPCClientTargetStats{
+			Requests:             th.requests.Sum(th.window),
+			Accepts:              th.accepts.Sum(th.window),
+			RejectionProbability: th.rejectionProbability(),
+		}
+
+		return true
+	})
+
+	return out
+}
+
+// rejectionProbability computes the probability of the local rejection of the next request.
+func (th *clientThrottler) rejectionProbability() float64 {
+	requests := float64(th.requests.Sum(th.window))
+	accepts := float64(th.accepts.Sum(th.window))
+
+	return max(0, (requests-th.k*accepts)/(requests+1))
+}
+
+// MakeUnaryClientInterceptor returns a unary client interceptor.
+func (g *grpcImpl) MakeUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		th := g.client.throttler(targetFromClientConn(cc))
+
+		if !g.client.allow(th) {
+			return status.Error(codes.ResourceExhausted, "request has been throttled on the client side")
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		g.client.report(th, err)
+
+		return err
+	}
+}
+
+// MakeStreamClientInterceptor returns a stream client interceptor.
+func (g *grpcImpl) MakeStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		th := g.client.throttler(targetFromClientConn(cc))
+
+		if !g.client.allow(th) {
+			return nil, status.Error(codes.ResourceExhausted, "request has been throttled on the client side")
+		}
+
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			g.client.report(th, err)
+
+			return nil, err
+		}
+
+		out := &clientStream{ClientStream: cs, client: g.client, throttler: th}
+
+		// Streams that are closed or cancelled before receiving anything haven't been rejected by the server,
+		// so they're accepted as soon as the context is done.
+		out.stop = context.AfterFunc(ctx, func() { out.once.Do(func() { g.client.report(th, nil) }) })
+
+		return out, nil
+	}
+}
+
+// clientStream reports the outcome of the stream as soon as the first response is received,
+// because the server interceptors reject streams before sending any message.
+// If nothing is received until the stream context is done, the stream is considered accepted.
+type clientStream struct {
+	grpc.ClientStream
+
+	client    *grpcClient
+	throttler *clientThrottler
+	// stop cancels reporting on the context done.
+	stop func() bool
+	once sync.Once
+}
+
+// RecvMsg receives a message and reports the outcome of the stream.
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	s.once.Do(func() {
+		s.stop()
+
+		if errors.Is(err, io.EOF) {
+			s.client.report(s.throttler, nil)
+
+			return
+		}
+
+		s.client.report(s.throttler, err)
+	})
+
+	return err
+}
+
+// targetFromClientConn returns the target of the client connection.
+func targetFromClientConn(cc *grpc.ClientConn) string {
+	if cc == nil {
+		return unknownGRPCTarget
+	}
+
+	return cc.Target()
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type clientStreamStub struct {
+	grpc.ClientStream
+
+	err error
+}
+
+func (s *clientStreamStub) RecvMsg(_ any) error { return s.err }
+
+func newTestGRPC(t *testing.T) *grpcImpl {
+	t.Helper()
+
+	//nolint:forcetypeassert // Test code.
+	return NewMiddleware(testr.New(t), &backpressureOperatorStub{allow: true}).GRPC().(*grpcImpl)
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	const requests = 100
+
+	t.Run("servers accept requests", func(t *testing.T) {
+		g := newTestGRPC(t)
+		interceptor := g.MakeUnaryClientInterceptor()
+
+		for range requests {
+			err := interceptor(
+				context.Background(),
+				"/test.Service/Unary",
+				nil,
+				nil,
+				nil,
+				func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+					return status.Error(codes.Internal, "not an overload")
+				},
+			)
+			require.Equal(t, codes.Internal, status.Code(err))
+		}
+
+		st := g.client.getStats()
+		require.Equal(t, uint64(requests), st.Requests)
+		require.Equal(t, uint64(requests), st.Accepted)
+		require.Zero(t, st.RejectedLocally)
+		require.Zero(t, st.RejectedRemotely)
+		require.Zero(t, st.Targets[unknownGRPCTarget].RejectionProbability)
+	})
+
+	t.Run("servers reject requests", func(t *testing.T) {
+		g := newTestGRPC(t)
+		interceptor := g.MakeUnaryClientInterceptor()
+
+		var invoked int
+
+		for range requests {
+			_ = interceptor(
+				context.Background(),
+				"/test.Service/Unary",
+				nil,
+				nil,
+				nil,
+				func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+					invoked++
+
+					return status.Error(codes.ResourceExhausted, "request has been throttled")
+				},
+			)
+		}
+
+		st := g.client.getStats()
+		require.Equal(t, uint64(requests), st.Requests)
+		require.Zero(t, st.Accepted)
+		require.Equal(t, uint64(invoked), st.RejectedRemotely)
+		require.Equal(t, uint64(requests-invoked), st.RejectedLocally)
+		require.NotZero(t, st.RejectedLocally)
+		require.Greater(t, st.Targets[unknownGRPCTarget].RejectionProbability, 0.9)
+	})
+}
+
+func TestUnaryClientInterceptorShadowMode(t *testing.T) {
+	const requests = 100
+
+	//nolint:forcetypeassert // Test code.
+	g := NewMiddleware(testr.New(t), &backpressureOperatorStub{allow: true}, WithShadowMode()).GRPC().(*grpcImpl)
+	interceptor := g.MakeUnaryClientInterceptor()
+
+	var invoked int
+
+	for range requests {
+		_ = interceptor(
+			context.Background(),
+			"/test.Service/Unary",
+			nil,
+			nil,
+			nil,
+			func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+				invoked++
+
+				return status.Error(codes.ResourceExhausted, "request has been throttled")
+			},
+		)
+	}
+
+	// Every request is sent, would-be local rejections are only counted.
+	require.Equal(t, requests, invoked)
+
+	st := g.client.getStats()
+	require.Equal(t, uint64(requests), st.RejectedRemotely)
+	require.Zero(t, st.RejectedLocally)
+	require.NotZero(t, st.ShadowRejectedLocally)
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	g := newTestGRPC(t)
+	interceptor := g.MakeStreamClientInterceptor()
+
+	open := func(ctx context.Context, recvErr error) grpc.ClientStream {
+		cs, err := interceptor(
+			ctx,
+			&grpc.StreamDesc{},
+			nil,
+			"/test.Service/Stream",
+			func(_ context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+				return &clientStreamStub{err: recvErr}, nil
+			},
+		)
+		require.NoError(t, err)
+
+		return cs
+	}
+
+	// The outcome is reported only once per stream.
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cs := open(ctx, io.EOF)
+	require.ErrorIs(t, cs.RecvMsg(nil), io.EOF)
+	require.ErrorIs(t, cs.RecvMsg(nil), io.EOF)
+
+	cs = open(ctx, status.Error(codes.ResourceExhausted, "request has been throttled"))
+	require.Error(t, cs.RecvMsg(nil))
+
+	// The stream cancelled without receiving anything is accepted.
+	open(ctx, nil)
+
+	cancel()
+
+	require.Eventually(t, func() bool { return g.client.getStats().Accepted == 2 }, time.Second, time.Millisecond)
+
+	st := g.client.getStats()
+	require.Equal(t, uint64(3), st.Requests)
+	require.Equal(t, uint64(2), st.Accepted)
+	require.Equal(t, uint64(1), st.RejectedRemotely)
+}
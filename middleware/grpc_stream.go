@@ -0,0 +1,199 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcStreams performs admission of the individual messages of the server-side streams.
+// It is safe for concurrent use.
+type grpcStreams struct {
+	cfg *StreamThrottlingConfig
+	// methods are per-method counters [string -> *streamCounters].
+	methods sync.Map
+}
+
+// streamCounters are the per-method stream counters.
+type streamCounters struct {
+	streams  atomic.Uint64
+	active   atomic.Int64
+	messages atomic.Uint64
+	bytes    atomic.Uint64
+	delayed  atomic.Uint64
+	rejected atomic.Uint64
+}
+
+func newGRPCStreams(cfg *StreamThrottlingConfig) *grpcStreams {
+	return &grpcStreams{cfg: cfg}
+}
+
+// counters returns counters for a particular method.
+func (s *grpcStreams) counters(method string) *streamCounters {
+	counters, ok := s.methods.Load(method)
+	if !ok {
+		counters, _ = s.methods.LoadOrStore(method, &streamCounters{})
+	}
+
+	//nolint:forcetypeassert // Only *streamCounters values are stored.
+	return counters.(*streamCounters)
+}
+
+// wrap makes server stream admit every incoming message. The stream must have been admitted already.
+func (s *grpcStreams) wrap(g *grpcImpl, ss grpc.ServerStream, method string) *serverStream {
+	counters := s.counters(method)
+	counters.streams.Add(1)
+
+	return &serverStream{
+		ServerStream: ss,
+		grpc:         g,
+		cfg:          s.cfg,
+		counters:     counters,
+		method:       method,
+		admitted:     true,
+	}
+}
+
+// getStats returns per-method statistics.
+func (s *grpcStreams) getStats() *stats.GRPCStreamStats {
+	out := &stats.GRPCStreamStats{
+		Methods: make(map[string]*stats.GRPCStreamMethodStats),
+	}
+
+	s.methods.Range(func(key, value any) bool {
+		//nolint:forcetypeassert // Only string keys and *streamCounters values are stored.
+		counters := value.(*streamCounters)
+
+		//nolint:forcetypeassert // Only string keys and *streamCounters values are stored.
+		out.Methods[key.(string)] = &stats.GRPCStreamMethodStats{
+			Streams:       counters.streams.Load(),
+			ActiveStreams: counters.active.Load(),
+			Messages:      counters.messages.Load(),
+			BytesReceived: counters.bytes.Load(),
+			Delayed:       counters.delayed.Load(),
+			Rejected:      counters.rejected.Load(),
+		}
+
+		return true
+	})
+
+	return out
+}
+
+// serverStream wraps grpc.ServerStream in order to perform admission of every incoming message.
+type serverStream struct {
+	grpc.ServerStream
+	grpc     *grpcImpl
+	cfg      *StreamThrottlingConfig
+	counters *streamCounters
+	method   string
+	// admitted is set until the first message is received: the stream has just been admitted,
+	// so the first message needs no separate decision.
+	admitted bool
+	// bytesReceived is the total size of the messages received within this stream.
+	bytesReceived atomic.Uint64
+}
+
+// RecvMsg waits for the message admission and receives the message.
+// Until the message is admitted, it's not read from the transport, so gRPC flow control
+// slows down the sender.
+func (s *serverStream) RecvMsg(m any) error {
+	if err := s.admit(); err != nil {
+		return err
+	}
+
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	s.counters.messages.Add(1)
+
+	if msg, ok := m.(proto.Message); ok {
+		size := uint64(proto.Size(msg)) //nolint:gosec // Size is never negative.
+		s.bytesReceived.Add(size)
+		s.counters.bytes.Add(size)
+	}
+
+	return nil
+}
+
+// admit asks backpressure operator for permission to receive the next message.
+// Messages are not requests, so the decisions are not registered in the throttling statistics.
+func (s *serverStream) admit() error {
+	if s.admitted {
+		s.admitted = false
+
+		return nil
+	}
+
+	if s.grpc.checkRequest(s.method) {
+		return nil
+	}
+
+	if s.cfg.Mode == StreamModeDelay {
+		s.counters.delayed.Add(1)
+
+		allowed, err := s.wait()
+		if err != nil {
+			return err
+		}
+
+		if allowed {
+			return nil
+		}
+	}
+
+	s.counters.rejected.Add(1)
+
+	s.grpc.streamThrottleLog.log(s.Context(), s.method)
+
+	return s.grpc.rejector.reject(s.Context(), s.method, "stream message has been throttled")
+}
+
+// wait repeats admission attempts until the message is admitted or the delay limit is exceeded.
+func (s *serverStream) wait() (bool, error) {
+	deadline := time.Now().Add(s.cfg.MaxDelay.Duration)
+
+	timer := time.NewTimer(s.cfg.RetryInterval.Duration)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.Context().Done():
+			return false, status.FromContextError(s.Context().Err()).Err()
+		case <-timer.C:
+		}
+
+		if s.grpc.checkRequest(s.method) {
+			return true, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return false, nil
+		}
+
+		timer.Reset(s.cfg.RetryInterval.Duration)
+	}
+}
+
+// StreamBytesReceived returns the total size of protobuf messages received within the server-side stream.
+// It returns zero if the stream has not been wrapped by the MemLimiter stream interceptor
+// (for instance, if per-message admission is disabled).
+func StreamBytesReceived(ss grpc.ServerStream) uint64 {
+	if s, ok := ss.(*serverStream); ok {
+		return s.bytesReceived.Load()
+	}
+
+	return 0
+}
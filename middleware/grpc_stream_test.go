@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func runStream(
+	t *testing.T,
+	m Middleware,
+	handler func(ss grpc.ServerStream) error,
+) error {
+	t.Helper()
+
+	interceptor := m.GRPC().MakeStreamServerInterceptor()
+
+	return interceptor(
+		struct{}{},
+		&serverStreamStub{},
+		&grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"},
+		func(_ any, ss grpc.ServerStream) error { return handler(ss) },
+	)
+}
+
+func TestStreamServerInterceptorPerMessage(t *testing.T) {
+	msg := wrapperspb.String("payload")
+	msgSize := uint64(proto.Size(msg))
+
+	t.Run("reject", func(t *testing.T) {
+		cfg := &StreamThrottlingConfig{Mode: StreamModeReject}
+		require.NoError(t, cfg.Prepare())
+
+		// The stream is admitted along with the first message, the second message is admitted,
+		// the third one is throttled.
+		operator := &backpressureOperatorStub{decisions: []bool{true, true, false}}
+		m := NewMiddleware(logr.Discard(), operator, WithConfig(&Config{GRPCStream: cfg}))
+
+		err := runStream(t, m, func(ss grpc.ServerStream) error {
+			require.NoError(t, ss.RecvMsg(msg))
+			require.Equal(t, msgSize, StreamBytesReceived(ss))
+			require.NoError(t, ss.RecvMsg(msg))
+
+			return ss.RecvMsg(msg)
+		})
+		require.Error(t, err)
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		// Only the stream itself is registered in the throttling statistics.
+		require.Equal(t, int64(1), operator.requests.Load())
+		require.Equal(t, int64(3), operator.calls.Load())
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+
+		methodStats := st.GRPCStream.Methods["/test.Service/Stream"]
+		require.NotNil(t, methodStats)
+		require.Equal(t, uint64(1), methodStats.Streams)
+		require.Equal(t, int64(0), methodStats.ActiveStreams)
+		require.Equal(t, uint64(2), methodStats.Messages)
+		require.Equal(t, 2*msgSize, methodStats.BytesReceived)
+		require.Equal(t, uint64(1), methodStats.Rejected)
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		cfg := &StreamThrottlingConfig{
+			Mode:          StreamModeDelay,
+			RetryInterval: duration.Duration{Duration: time.Millisecond},
+			MaxDelay:      duration.Duration{Duration: time.Minute},
+		}
+		require.NoError(t, cfg.Prepare())
+
+		// The stream is admitted along with the first message, the second message is admitted
+		// on the third attempt.
+		operator := &backpressureOperatorStub{decisions: []bool{true, false, false, true}}
+		m := NewMiddleware(logr.Discard(), operator, WithConfig(&Config{GRPCStream: cfg}))
+
+		err := runStream(t, m, func(ss grpc.ServerStream) error {
+			require.NoError(t, ss.RecvMsg(msg))
+
+			return ss.RecvMsg(msg)
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), operator.requests.Load())
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+
+		methodStats := st.GRPCStream.Methods["/test.Service/Stream"]
+		require.Equal(t, uint64(1), methodStats.Delayed)
+		require.Equal(t, uint64(0), methodStats.Rejected)
+		require.Equal(t, uint64(2), methodStats.Messages)
+	})
+
+	t.Run("delay limit exceeded", func(t *testing.T) {
+		cfg := &StreamThrottlingConfig{
+			Mode:          StreamModeDelay,
+			RetryInterval: duration.Duration{Duration: time.Millisecond},
+			MaxDelay:      duration.Duration{Duration: 10 * time.Millisecond},
+		}
+		require.NoError(t, cfg.Prepare())
+
+		operator := &backpressureOperatorStub{decisions: []bool{true, false}}
+		m := NewMiddleware(logr.Discard(), operator, WithConfig(&Config{GRPCStream: cfg}))
+
+		err := runStream(t, m, func(ss grpc.ServerStream) error {
+			require.NoError(t, ss.RecvMsg(msg))
+
+			return ss.RecvMsg(msg)
+		})
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		operator := &backpressureOperatorStub{decisions: []bool{true, false}}
+		m := NewMiddleware(logr.Discard(), operator)
+
+		err := runStream(t, m, func(ss grpc.ServerStream) error { return ss.RecvMsg(msg) })
+		require.NoError(t, err)
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+		require.Nil(t, st.GRPCStream)
+	})
+}
@@ -8,15 +8,18 @@ package middleware
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 
 	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
 	"github.com/newcloudtechnologies/memlimiter/stats"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/tap"
 )
 
 type logRecord struct {
@@ -63,16 +66,61 @@ func (s *captureSink) WithValues(keysAndValues ...any) logr.LogSink {
 	}
 }
 
+// backpressureOperatorStub allows requests either according to the predefined sequence of decisions
+// (when the sequence is exhausted, the last decision is repeated) or according to allow flag,
+// and reports the given memory pressure.
 type backpressureOperatorStub struct {
-	allow bool
+	allow     bool
+	decisions []bool
+	pressure  backpressure.Pressure
+	// calls is the total number of decisions.
+	calls atomic.Int64
+	// requests is the number of decisions registered in the throttling statistics.
+	requests atomic.Int64
+	// pressureCalls is the number of Pressure calls.
+	pressureCalls atomic.Int64
+	// statsCalls is the number of GetStats calls.
+	statsCalls atomic.Int64
 }
 
 func (b *backpressureOperatorStub) SetControlParameters(_ *stats.ControlParameters) error { return nil }
 
-func (b *backpressureOperatorStub) AllowRequest() bool { return b.allow }
+func (b *backpressureOperatorStub) AllowRequest() bool {
+	b.requests.Add(1)
+
+	return b.CheckRequest()
+}
+
+func (b *backpressureOperatorStub) CheckRequest() bool {
+	ix := int(b.calls.Add(1)) - 1
+
+	if len(b.decisions) == 0 {
+		return b.allow
+	}
+
+	return b.decisions[min(ix, len(b.decisions)-1)]
+}
+
+func (b *backpressureOperatorStub) Pressure() backpressure.Pressure {
+	b.pressureCalls.Add(1)
+
+	return b.pressure
+}
 
 func (b *backpressureOperatorStub) GetStats() (*stats.BackpressureStats, error) {
-	return &stats.BackpressureStats{}, nil
+	b.statsCalls.Add(1)
+
+	return &stats.BackpressureStats{
+		ControlParameters: &stats.ControlParameters{
+			ControllerStats: &stats.ControllerStats{
+				MemoryBudget: &stats.MemoryBudgetStats{
+					Zone:        b.pressure.Zone,
+					Utilization: b.pressure.Utilization,
+				},
+			},
+			ThrottlingPercentage: b.pressure.ThrottlingPercentage,
+		},
+	}, nil
 }
 
 func (b *backpressureOperatorStub) Quit() {}
@@ -93,9 +141,12 @@ func (s *serverStreamStub) RecvMsg(_ any) error { return nil }
 
 func TestUnaryServerInterceptorLogsMethodOnThrottling(t *testing.T) {
 	sink := newCaptureSink()
+	operator := &backpressureOperatorStub{allow: false}
 	g := &grpcImpl{
-		backpressureOperator: &backpressureOperatorStub{allow: false},
+		backpressureOperator: operator,
+		rejector:             newRejector(operator, nil, nil),
 		logger:               logr.New(sink),
+		throttleLog:          newThrottleLogger(logr.New(sink), testThrottleLoggingConfig(), "request has been throttled", "", "grpc_method"),
 	}
 
 	interceptor := g.MakeUnaryServerInterceptor()
@@ -126,9 +177,12 @@ func TestUnaryServerInterceptorLogsMethodOnThrottling(t *testing.T) {
 
 func TestStreamServerInterceptorLogsMethodOnThrottling(t *testing.T) {
 	sink := newCaptureSink()
+	operator := &backpressureOperatorStub{allow: false}
 	g := &grpcImpl{
-		backpressureOperator: &backpressureOperatorStub{allow: false},
+		backpressureOperator: operator,
+		rejector:             newRejector(operator, nil, nil),
 		logger:               logr.New(sink),
+		throttleLog:          newThrottleLogger(logr.New(sink), testThrottleLoggingConfig(), "request has been throttled", "", "grpc_method"),
 	}
 
 	interceptor := g.MakeStreamServerInterceptor()
@@ -167,3 +221,222 @@ func keyValueByName(kv []any, key string) (any, bool) {
 
 	return nil, false
 }
+
+func TestServerInterceptorsShadowMode(t *testing.T) {
+	m := NewMiddleware(logr.Discard(), &backpressureOperatorStub{allow: false}, WithShadowMode())
+
+	unary := m.GRPC().MakeUnaryServerInterceptor()
+
+	for range 2 {
+		resp, err := unary(
+			context.Background(),
+			"struct{}{}",
+			&grpc.UnaryServerInfo{FullMethod: "/test.Service/Unary"},
+			func(_ context.Context, _ any) (any, error) { return "ok", nil },
+		)
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp)
+	}
+
+	stream := m.GRPC().MakeStreamServerInterceptor()
+
+	err := stream(
+		struct{}{},
+		&serverStreamStub{},
+		&grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"},
+		func(_ any, _ grpc.ServerStream) error { return nil },
+	)
+	require.NoError(t, err)
+
+	st, err := m.GetStats()
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		map[string]uint64{"/test.Service/Unary": 2, "/test.Service/Stream": 1},
+		st.ShadowThrottled,
+	)
+}
+
+func TestServerInterceptorsInFlightCancellation(t *testing.T) {
+	registry, err := backpressure.NewInFlightRegistry(
+		logr.Discard(),
+		&backpressure.InFlightConfig{CriticalZone: 90},
+	)
+	require.NoError(t, err)
+
+	m := NewMiddleware(
+		logr.Discard(),
+		backpressure.NewCompositeOperator(&backpressureOperatorStub{allow: true}, registry),
+		WithInFlightRegistry(registry),
+		WithPriorityFunc(func(_ context.Context, method string) int {
+			if method == "/test.Service/Important" {
+				return 1
+			}
+
+			return 0
+		}),
+	)
+
+	critical := &stats.ControlParameters{
+		ControllerStats: &stats.ControllerStats{
+			MemoryBudget: &stats.MemoryBudgetStats{Utilization: 1},
+		},
+	}
+
+	unary := m.GRPC().MakeUnaryServerInterceptor()
+	stream := m.GRPC().MakeStreamServerInterceptor()
+
+	// The unary request has higher priority, so the stream is cancelled first.
+	_, err = unary(
+		context.Background(),
+		struct{}{},
+		&grpc.UnaryServerInfo{FullMethod: "/test.Service/Important"},
+		func(ctx context.Context, _ any) (any, error) {
+			err := stream(
+				struct{}{},
+				&serverStreamStub{},
+				&grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"},
+				func(_ any, ss grpc.ServerStream) error {
+					require.NoError(t, registry.SetControlParameters(critical))
+					require.Error(t, ss.Context().Err())
+					require.NoError(t, ctx.Err())
+
+					return ss.Context().Err()
+				},
+			)
+			require.Equal(t, codes.Unavailable, status.Code(err))
+
+			return "ok", nil
+		},
+	)
+	require.NoError(t, err)
+
+	st, err := m.GetStats()
+	require.NoError(t, err)
+	require.Equal(t, map[string]uint64{"/test.Service/Stream": 1}, st.Cancelled)
+}
+
+func TestServerInterceptorsExemptMethodsAreNotCancelled(t *testing.T) {
+	registry, err := backpressure.NewInFlightRegistry(
+		logr.Discard(),
+		&backpressure.InFlightConfig{CriticalZone: 90},
+	)
+	require.NoError(t, err)
+
+	m := NewMiddleware(
+		logr.Discard(),
+		backpressure.NewCompositeOperator(&backpressureOperatorStub{allow: true}, registry),
+		WithInFlightRegistry(registry),
+		WithConfig(&Config{GRPCServer: &ServerConfig{ExemptMethods: []string{"/grpc.health.v1.Health/*"}}}),
+	)
+
+	critical := &stats.ControlParameters{
+		ControllerStats: &stats.ControllerStats{
+			MemoryBudget: &stats.MemoryBudgetStats{Utilization: 1},
+		},
+	}
+
+	_, err = m.GRPC().MakeUnaryServerInterceptor()(
+		context.Background(),
+		struct{}{},
+		&grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"},
+		func(ctx context.Context, _ any) (any, error) {
+			err := m.GRPC().MakeStreamServerInterceptor()(
+				struct{}{},
+				&serverStreamStub{},
+				&grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch"},
+				func(_ any, ss grpc.ServerStream) error {
+					require.NoError(t, registry.SetControlParameters(critical))
+
+					return ss.Context().Err()
+				},
+			)
+			require.NoError(t, err)
+
+			return "ok", ctx.Err()
+		},
+	)
+	require.NoError(t, err)
+
+	backpressureStats, err := registry.GetStats()
+	require.NoError(t, err)
+	require.Zero(t, backpressureStats.Cancellation.Cancelled)
+}
+
+func TestTapHandle(t *testing.T) {
+	t.Run("throttling", func(t *testing.T) {
+		m := NewMiddleware(
+			logr.Discard(),
+			&backpressureOperatorStub{allow: false},
+			WithConfig(&Config{GRPCServer: &ServerConfig{ExemptMethods: []string{"/grpc.health.v1.Health/*"}}}),
+		)
+
+		handle := m.GRPC().MakeTapHandle()
+
+		_, err := handle(context.Background(), &tap.Info{FullMethodName: "/test.Service/Unary"})
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		ctx, err := handle(context.Background(), &tap.Info{FullMethodName: "/grpc.health.v1.Health/Check"})
+		require.NoError(t, err)
+		require.NotNil(t, ctx)
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			map[string]*stats.GRPCServerMethodStats{
+				"/test.Service/Unary":          {Requests: 1, Throttled: 1},
+				"/grpc.health.v1.Health/Check": {Requests: 1, Exempted: 1},
+			},
+			st.GRPCServer.Methods,
+		)
+	})
+
+	t.Run("unknown methods", func(t *testing.T) {
+		m := NewMiddleware(logr.Discard(), &backpressureOperatorStub{allow: true})
+		m.GRPC().SetServiceInfo(map[string]grpc.ServiceInfo{
+			"test.Service": {Methods: []grpc.MethodInfo{{Name: "Unary"}}},
+		})
+
+		handle := m.GRPC().MakeTapHandle()
+
+		for _, method := range []string{"/test.Service/Unary", "/bogus.Service/A", "/bogus.Service/B"} {
+			_, err := handle(context.Background(), &tap.Info{FullMethodName: method})
+			require.NoError(t, err)
+		}
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			map[string]*stats.GRPCServerMethodStats{
+				"/test.Service/Unary": {Requests: 1},
+				unknownGRPCMethod:     {Requests: 2},
+			},
+			st.GRPCServer.Methods,
+		)
+	})
+
+	t.Run("no double admission", func(t *testing.T) {
+		// Tap handle admits the request, interceptor would have throttled it if asked.
+		operator := &backpressureOperatorStub{decisions: []bool{true, false}}
+		m := NewMiddleware(logr.Discard(), operator)
+
+		ctx, err := m.GRPC().MakeTapHandle()(context.Background(), &tap.Info{FullMethodName: "/test.Service/Unary"})
+		require.NoError(t, err)
+
+		resp, err := m.GRPC().MakeUnaryServerInterceptor()(
+			ctx,
+			struct{}{},
+			&grpc.UnaryServerInfo{FullMethod: "/test.Service/Unary"},
+			func(_ context.Context, _ any) (any, error) { return "ok", nil },
+		)
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp)
+		require.Equal(t, int64(1), operator.calls.Load())
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), st.GRPCServer.Methods["/test.Service/Unary"].Requests)
+	})
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+const (
+	// percents is a constant for converting throttling percentage to share.
+	percents = 100
+	// defaultHTTPRoute is the route of all the non-exempt requests if RouteFunc is not provided.
+	defaultHTTPRoute = "<default>"
+	// cancelledMessage is the response body of the requests cancelled by in-flight registry.
+	cancelledMessage = "request has been cancelled due to memory pressure"
+)
+
+// HTTP provides net/http integration.
+type HTTP interface {
+	// MakeHandler wraps handler, so that requests are rejected under memory pressure.
+	// Rejected requests get configured status code (503 or 429) and Retry-After header
+	// growing with the share of throttled requests.
+	MakeHandler(next http.Handler) http.Handler
+}
+
+// RouteFunc returns the route of the request used for exemptions and statistics.
+// Routes should have low cardinality, e.g. "/users/{id}" rather than "/users/42".
+// If it's not provided, URL paths are matched against exempt routes, but they're never used as routes
+// since clients may send any of them: exempt requests are counted under the matching pattern,
+// and all the other ones are counted under the single "<default>" route.
+type RouteFunc func(r *http.Request) string
+
+// httpImpl is the implementation of the HTTP interface.
+type httpImpl struct {
+	backpressureOperator backpressure.Operator
+	cfg                  *HTTPConfig
+	// route is nil if RouteFunc is not provided.
+	route RouteFunc
+	// routes are per-route counters.
+	routes admissionRegistry
+	// shadow is not nil in the shadow mode.
+	shadow *methodCounters
+	// inFlight is not nil if in-flight requests cancellation is enabled.
+	inFlight backpressure.InFlightRegistry
+	priority PriorityFunc
+	// cancelled counts requests cancelled by inFlight registry.
+	cancelled *methodCounters
+	// throttleLog logs the throttled requests.
+	throttleLog *throttleLogger
+}
+
+// MakeHandler wraps handler.
+func (h *httpImpl) MakeHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, exempt := h.classify(r)
+
+		counters := h.routes.get(route)
+		counters.requests.Add(1)
+
+		if exempt {
+			counters.exempted.Add(1)
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		if allowRequest(h.backpressureOperator, h.shadow, route) {
+			h.serve(w, r, route, next)
+
+			return
+		}
+
+		counters.throttled.Add(1)
+
+		h.throttleLog.log(r.Context(), route)
+
+		w.Header().Set("Retry-After", strconv.Itoa(h.retryAfter()))
+		http.Error(w, "request has been throttled", h.cfg.StatusCode)
+	})
+}
+
+// serve calls handler, registering request in the in-flight registry if necessary.
+func (h *httpImpl) serve(w http.ResponseWriter, r *http.Request, route string, next http.Handler) {
+	if h.inFlight == nil {
+		next.ServeHTTP(w, r)
+
+		return
+	}
+
+	var size uint64
+	if r.ContentLength > 0 {
+		size = uint64(r.ContentLength)
+	}
+
+	request := h.inFlight.Track(r.Context(), h.priority(r.Context(), route), size)
+	defer request.Done()
+
+	tracked := &trackingResponseWriter{ResponseWriter: w}
+
+	next.ServeHTTP(tracked, r.WithContext(request.Context()))
+
+	if !errors.Is(context.Cause(request.Context()), backpressure.ErrRequestCancelled) {
+		return
+	}
+
+	h.cancelled.register(route)
+
+	// Otherwise the cancelled request would end up with implicit 200.
+	if !tracked.started {
+		w.Header().Set("Retry-After", strconv.Itoa(h.retryAfter()))
+		http.Error(w, cancelledMessage, http.StatusServiceUnavailable)
+	}
+}
+
+// classify returns the route of the request and whether it's exempt from admission.
+func (h *httpImpl) classify(r *http.Request) (string, bool) {
+	if h.route != nil {
+		route := h.route(r)
+
+		return route, matchAny(h.cfg.ExemptRoutes, route)
+	}
+
+	requestPath := pathFromRequest(r)
+
+	for _, pattern := range h.cfg.ExemptRoutes {
+		if matched, _ := path.Match(pattern, requestPath); matched {
+			return pattern, true
+		}
+	}
+
+	return defaultHTTPRoute, false
+}
+
+// retryAfter computes Retry-After value in seconds according to the applied share of throttled requests.
+func (h *httpImpl) retryAfter() int {
+	minDelay, maxDelay := h.cfg.RetryAfterMin.Duration, h.cfg.RetryAfterMax.Duration
+
+	share := float64(backpressure.GetPressure(h.backpressureOperator).ThrottlingPercentage) / percents
+
+	delay := minDelay + time.Duration(share*float64(maxDelay-minDelay))
+
+	return int(math.Ceil(delay.Seconds()))
+}
+
+// getStats returns per-route statistics.
+func (h *httpImpl) getStats() *stats.HTTPStats {
+	out := &stats.HTTPStats{
+		Routes: make(map[string]*stats.HTTPRouteStats),
+	}
+
+	h.routes.rangeCounters(func(route string, counters *admissionCounters) {
+		out.Routes[route] = &stats.HTTPRouteStats{
+			Requests:  counters.requests.Load(),
+			Throttled: counters.throttled.Load(),
+			Exempted:  counters.exempted.Load(),
+		}
+	})
+
+	return out
+}
+
+// pathFromRequest returns URL path of the request.
+func pathFromRequest(r *http.Request) string {
+	if r.URL == nil || r.URL.Path == "" {
+		return "/"
+	}
+
+	return r.URL.Path
+}
+
+// trackingResponseWriter remembers whether the response has been started.
+type trackingResponseWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *trackingResponseWriter) WriteHeader(statusCode int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *trackingResponseWriter) Write(data []byte) (int, error) {
+	w.started = true
+
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush implements http.Flusher if the underlying writer supports it.
+func (w *trackingResponseWriter) Flush() {
+	w.started = true
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap makes http.ResponseController reach the underlying writer.
+func (w *trackingResponseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
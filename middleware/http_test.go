@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(handler http.Handler, target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+		return recorder
+	}
+
+	t.Run("throttling", func(t *testing.T) {
+		cfg := &HTTPConfig{
+			StatusCode:    http.StatusTooManyRequests,
+			RetryAfterMin: duration.Duration{Duration: 2 * time.Second},
+			RetryAfterMax: duration.Duration{Duration: 12 * time.Second},
+			ExemptRoutes:  []string{"/healthz", "/debug/*"},
+		}
+		require.NoError(t, cfg.Prepare())
+
+		operator := &backpressureOperatorStub{
+			allow:    false,
+			pressure: backpressure.Pressure{ThrottlingPercentage: 50},
+		}
+
+		m := NewMiddleware(logr.Discard(), operator, WithConfig(&Config{HTTP: cfg}))
+		handler := m.HTTP().MakeHandler(okHandler)
+
+		recorder := serve(handler, "/api")
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		require.Equal(t, "7", recorder.Header().Get("Retry-After"))
+		require.Zero(t, operator.statsCalls.Load())
+
+		require.Equal(t, http.StatusOK, serve(handler, "/healthz").Code)
+		require.Equal(t, http.StatusOK, serve(handler, "/debug/vars").Code)
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+		// paths are not used as routes by default
+		require.Equal(t, map[string]*stats.HTTPRouteStats{
+			defaultHTTPRoute: {Requests: 1, Throttled: 1},
+			"/healthz":       {Requests: 1, Exempted: 1},
+			"/debug/*":       {Requests: 1, Exempted: 1},
+		}, st.HTTP.Routes)
+	})
+
+	t.Run("route func", func(t *testing.T) {
+		m := NewMiddleware(
+			logr.Discard(),
+			&backpressureOperatorStub{allow: true},
+			WithHTTPRouteFunc(func(_ *http.Request) string { return "/users/{id}" }),
+		)
+		handler := m.HTTP().MakeHandler(okHandler)
+
+		require.Equal(t, http.StatusOK, serve(handler, "/users/1").Code)
+		require.Equal(t, http.StatusOK, serve(handler, "/users/2").Code)
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, map[string]*stats.HTTPRouteStats{"/users/{id}": {Requests: 2}}, st.HTTP.Routes)
+	})
+
+	t.Run("shadow mode", func(t *testing.T) {
+		m := NewMiddleware(logr.Discard(), &backpressureOperatorStub{allow: false}, WithShadowMode())
+		handler := m.HTTP().MakeHandler(okHandler)
+
+		require.Equal(t, http.StatusOK, serve(handler, "/api").Code)
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, map[string]uint64{defaultHTTPRoute: 1}, st.ShadowThrottled)
+	})
+
+	t.Run("routes limit", func(t *testing.T) {
+		m := NewMiddleware(
+			logr.Discard(),
+			&backpressureOperatorStub{allow: true},
+			WithHTTPRouteFunc(func(r *http.Request) string { return r.URL.Path }),
+		)
+		handler := m.HTTP().MakeHandler(okHandler)
+
+		for i := range maxRegisteredNames + 10 {
+			serve(handler, "/users/"+strconv.Itoa(i))
+		}
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+		require.Len(t, st.HTTP.Routes, maxRegisteredNames+1)
+		require.Equal(t, &stats.HTTPRouteStats{Requests: 10}, st.HTTP.Routes[otherName])
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		registry, err := backpressure.NewInFlightRegistry(logr.Discard(), &backpressure.InFlightConfig{CriticalZone: 90})
+		require.NoError(t, err)
+
+		m := NewMiddleware(
+			logr.Discard(),
+			backpressure.NewCompositeOperator(&backpressureOperatorStub{allow: true}, registry),
+			WithInFlightRegistry(registry),
+		)
+
+		critical := &stats.ControlParameters{
+			ControllerStats: &stats.ControllerStats{
+				MemoryBudget: &stats.MemoryBudgetStats{Utilization: 1},
+			},
+		}
+
+		cancelled := func(write bool) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if write {
+					w.WriteHeader(http.StatusAccepted)
+				}
+
+				require.NoError(t, registry.SetControlParameters(critical))
+				<-r.Context().Done()
+			})
+		}
+
+		// the response has not been started, so the request is rejected explicitly
+		recorder := serve(m.HTTP().MakeHandler(cancelled(false)), "/api")
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+		// the response that has been started is left as is
+		recorder = serve(m.HTTP().MakeHandler(cancelled(true)), "/api")
+		require.Equal(t, http.StatusAccepted, recorder.Code)
+
+		st, err := m.GetStats()
+		require.NoError(t, err)
+		require.Equal(t, map[string]uint64{defaultHTTPRoute: 2}, st.Cancelled)
+	})
+}
+
+func TestHTTPConfig(t *testing.T) {
+	c := &HTTPConfig{}
+	require.NoError(t, c.Prepare())
+	require.Equal(t, http.StatusServiceUnavailable, c.StatusCode)
+
+	for _, c := range []*HTTPConfig{
+		{StatusCode: http.StatusInternalServerError},
+		{RetryAfterMin: duration.Duration{Duration: time.Millisecond}},
+		{RetryAfterMin: duration.Duration{Duration: time.Minute}, RetryAfterMax: duration.Duration{Duration: time.Second}},
+		{ExemptRoutes: []string{"["}},
+	} {
+		require.Error(t, c.Prepare())
+	}
+}
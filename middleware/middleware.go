@@ -7,33 +7,160 @@
 package middleware
 
 import (
+	"context"
+
 	"github.com/go-logr/logr"
 	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
 )
 
 // Middleware - extendable type responsible for MemLimiter integration with
 // various web and microservice frameworks.
 type Middleware interface {
 	GRPC() GRPC
+	HTTP() HTTP
 	// TODO: add new frameworks here
+
+	// GetStats returns middleware statistics.
+	GetStats() (*stats.MiddlewareStats, error)
+	// Quit writes the pending summaries of the throttled requests and stops background activity.
+	Quit()
 }
 
 type middlewareImpl struct {
-	backpressureOperator backpressure.Operator
-	logger               logr.Logger
+	grpc *grpcImpl
+	http *httpImpl
 }
 
-func (m *middlewareImpl) GRPC() GRPC {
-	return &grpcImpl{
-		logger:               m.logger,
-		backpressureOperator: m.backpressureOperator,
+func (m *middlewareImpl) GRPC() GRPC { return m.grpc }
+
+func (m *middlewareImpl) HTTP() HTTP { return m.http }
+
+func (m *middlewareImpl) GetStats() (*stats.MiddlewareStats, error) {
+	out := &stats.MiddlewareStats{
+		GRPCServer: m.grpc.getServerStats(),
+		GRPCClient: m.grpc.client.getStats(),
+		HTTP:       m.http.getStats(),
 	}
+
+	if m.grpc.streams != nil {
+		out.GRPCStream = m.grpc.streams.getStats()
+	}
+
+	if m.grpc.inFlight != nil {
+		out.Cancelled = m.grpc.cancelled.getStats()
+	}
+
+	if m.grpc.shadow != nil {
+		out.ShadowThrottled = m.grpc.shadow.getStats()
+	}
+
+	return out, nil
+}
+
+func (m *middlewareImpl) Quit() {
+	m.grpc.throttleLog.stop()
+	m.grpc.streamThrottleLog.stop()
+	m.http.throttleLog.stop()
 }
 
 // NewMiddleware creates new middleware instance.
-func NewMiddleware(logger logr.Logger, operator backpressure.Operator) Middleware {
-	return &middlewareImpl{
-		logger:               logger,
-		backpressureOperator: operator,
+func NewMiddleware(logger logr.Logger, operator backpressure.Operator, options ...Option) Middleware {
+	var (
+		cfg      *Config
+		shadow   *methodCounters
+		inFlight backpressure.InFlightRegistry
+		priority PriorityFunc
+		route    RouteFunc
+		reject   RejectionFunc
+	)
+
+	for _, op := range options {
+		switch t := op.(type) {
+		case *configOption:
+			cfg = t.val
+		case *shadowModeOption:
+			shadow = &methodCounters{}
+		case *inFlightRegistryOption:
+			inFlight = t.val
+		case *priorityFuncOption:
+			priority = t.val
+		case *httpRouteFuncOption:
+			route = t.val
+		case *rejectionFuncOption:
+			reject = t.val
+		}
+	}
+
+	if priority == nil {
+		priority = func(context.Context, string) int { return 0 }
+	}
+
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	clientCfg := cfg.GRPCClient
+	if clientCfg == nil {
+		clientCfg = &ClientThrottlingConfig{}
+		clientCfg.applyDefaults()
+	}
+
+	httpCfg := cfg.HTTP
+	if httpCfg == nil {
+		httpCfg = &HTTPConfig{}
+		httpCfg.applyDefaults()
 	}
+
+	throttleLogCfg := cfg.ThrottleLogging
+	if throttleLogCfg == nil {
+		throttleLogCfg = &ThrottleLoggingConfig{}
+	}
+
+	cancelled := &methodCounters{}
+
+	out := &middlewareImpl{
+		grpc: &grpcImpl{
+			logger:               logger,
+			backpressureOperator: operator,
+			client:               newGRPCClient(clientCfg, shadow != nil),
+			shadow:               shadow,
+			inFlight:             inFlight,
+			priority:             priority,
+			cancelled:            cancelled,
+			throttleLog: newThrottleLogger(
+				logger, throttleLogCfg, "request has been throttled", "throttled gRPC requests", "grpc_method",
+			),
+			streamThrottleLog: newThrottleLogger(
+				logger, throttleLogCfg, "stream message has been throttled", "throttled gRPC stream messages", "grpc_method",
+			),
+		},
+		http: &httpImpl{
+			backpressureOperator: operator,
+			cfg:                  httpCfg,
+			route:                route,
+			shadow:               shadow,
+			inFlight:             inFlight,
+			priority:             priority,
+			cancelled:            cancelled,
+			throttleLog: newThrottleLogger(
+				logger, throttleLogCfg, "request has been throttled", "throttled HTTP requests", "http_route",
+			),
+		},
+	}
+
+	var rejectionCfg *RejectionConfig
+
+	if cfg.GRPCServer != nil {
+		out.grpc.exemptMethods = cfg.GRPCServer.ExemptMethods
+		rejectionCfg = cfg.GRPCServer.Rejection
+	}
+
+	out.grpc.rejector = newRejector(operator, rejectionCfg, reject)
+
+	if cfg.GRPCStream != nil {
+		out.grpc.streams = newGRPCStreams(cfg.GRPCStream)
+	}
+
+	return out
 }
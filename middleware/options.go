@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"context"
+
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+)
+
+// Option - middleware constructor options.
+type Option interface {
+	anchor()
+}
+
+type configOption struct {
+	val *Config
+}
+
+func (o configOption) anchor() {}
+
+// WithConfig provides middleware configuration. The config is expected to be already prepared.
+func WithConfig(cfg *Config) Option {
+	return &configOption{val: cfg}
+}
+
+type shadowModeOption struct{}
+
+func (o shadowModeOption) anchor() {}
+
+// WithShadowMode makes middleware run in the shadow (dry-run) mode: requests are never rejected,
+// but the ones that would have been throttled are counted per method.
+func WithShadowMode() Option {
+	return &shadowModeOption{}
+}
+
+type inFlightRegistryOption struct {
+	val backpressure.InFlightRegistry
+}
+
+func (o inFlightRegistryOption) anchor() {}
+
+// WithInFlightRegistry makes server interceptors register admitted requests in the registry,
+// so that they can be cancelled at critical memory pressure. Cancelled requests end with Unavailable code.
+// The registry must be a part of the backpressure operator passed to MemLimiter.
+func WithInFlightRegistry(registry backpressure.InFlightRegistry) Option {
+	return &inFlightRegistryOption{val: registry}
+}
+
+// PriorityFunc returns the priority of the request: requests with lower values are cancelled first.
+type PriorityFunc func(ctx context.Context, method string) int
+
+type priorityFuncOption struct {
+	val PriorityFunc
+}
+
+func (o priorityFuncOption) anchor() {}
+
+// WithPriorityFunc provides request priorities for the in-flight registry (method argument is the gRPC method
+// or the HTTP route). All requests have zero priority by default.
+func WithPriorityFunc(f PriorityFunc) Option {
+	return &priorityFuncOption{val: f}
+}
+
+type httpRouteFuncOption struct {
+	val RouteFunc
+}
+
+func (o httpRouteFuncOption) anchor() {}
+
+// WithHTTPRouteFunc provides the way of extracting routes from HTTP requests. By default, the requests
+// are not split by routes (see RouteFunc).
+func WithHTTPRouteFunc(f RouteFunc) Option {
+	return &httpRouteFuncOption{val: f}
+}
+
+type rejectionFuncOption struct {
+	val RejectionFunc
+}
+
+func (o rejectionFuncOption) anchor() {}
+
+// WithRejectionFunc provides a hook building errors for the throttled gRPC requests and stream messages,
+// so that application can use its own status codes, messages and details.
+func WithRejectionFunc(f RejectionFunc) Option {
+	return &rejectionFuncOption{val: f}
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// RejectionReason - reason of google.rpc.ErrorInfo attached to the rejected requests statuses.
+	RejectionReason = "MEMORY_PRESSURE"
+	// RejectionDomain - domain of google.rpc.ErrorInfo attached to the rejected requests statuses.
+	RejectionDomain = "memlimiter"
+)
+
+// RejectionInfo - information about the rejected request.
+type RejectionInfo struct {
+	// Method - full gRPC method name.
+	Method string
+	// Code - status code chosen according to the rejection policy.
+	Code codes.Code
+	// Message - status message rendered according to the rejection policy.
+	Message string
+	// Zone - memory budget utilization zone.
+	Zone stats.Zone
+	// Utilization - memory budget utilization ratio.
+	Utilization float64
+	// ThrottlingPercentage - applied percentage of requests being throttled.
+	ThrottlingPercentage uint32
+}
+
+// RejectionFunc builds the error returned for the rejected request. If it returns nil,
+// the error built according to the rejection policy is used. It's called for every rejected request,
+// so it must be fast.
+type RejectionFunc func(ctx context.Context, info *RejectionInfo) error
+
+// rejector builds errors for the rejected requests according to the rejection policy.
+type rejector struct {
+	operator backpressure.Operator
+	cfg      *RejectionConfig
+	// message is nil if default message is used.
+	message *template.Template
+	// custom is optional user-defined error builder.
+	custom RejectionFunc
+}
+
+func newRejector(operator backpressure.Operator, cfg *RejectionConfig, custom RejectionFunc) *rejector {
+	if cfg == nil {
+		cfg = &RejectionConfig{Code: codes.ResourceExhausted}
+	}
+
+	out := &rejector{
+		operator: operator,
+		cfg:      cfg,
+		custom:   custom,
+	}
+
+	if cfg.Message != "" {
+		// The config is expected to be prepared, so parsing errors are not possible here.
+		if message, err := template.New("message").Parse(cfg.Message); err == nil {
+			out.message = message
+		}
+	}
+
+	return out
+}
+
+// reject returns error for the rejected request; defaultMessage is used if message template is not set.
+func (r *rejector) reject(ctx context.Context, method, defaultMessage string) error {
+	info := &RejectionInfo{
+		Method: method,
+		Code:   r.code(method),
+	}
+
+	// Memory pressure is requested only if somebody needs it: requests are rejected exactly when memory is short.
+	if r.message != nil || r.cfg.Details || r.custom != nil {
+		pressure := backpressure.GetPressure(r.operator)
+		info.Zone = pressure.Zone
+		info.Utilization = pressure.Utilization
+		info.ThrottlingPercentage = pressure.ThrottlingPercentage
+	}
+
+	info.Message = r.renderMessage(info, defaultMessage)
+
+	if r.custom != nil {
+		if customErr := r.custom(ctx, info); customErr != nil {
+			return customErr
+		}
+	}
+
+	st := status.New(info.Code, info.Message)
+
+	if !r.cfg.Details {
+		return st.Err()
+	}
+
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: RejectionReason,
+		Domain: RejectionDomain,
+		Metadata: map[string]string{
+			"zone":                  info.Zone.String(),
+			"utilization":           strconv.FormatFloat(info.Utilization, 'f', -1, 64),
+			"throttling_percentage": strconv.FormatUint(uint64(info.ThrottlingPercentage), 10),
+		},
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// code returns status code for the method.
+func (r *rejector) code(method string) codes.Code {
+	for _, methodCfg := range r.cfg.Methods {
+		if matched, _ := path.Match(methodCfg.Pattern, method); matched {
+			return methodCfg.Code
+		}
+	}
+
+	return r.cfg.Code
+}
+
+// renderMessage renders status message; defaultMessage is used if template is not set or fails.
+func (r *rejector) renderMessage(info *RejectionInfo, defaultMessage string) string {
+	if r.message == nil {
+		return defaultMessage
+	}
+
+	var out strings.Builder
+
+	if err := r.message.Execute(&out, info); err != nil {
+		return defaultMessage
+	}
+
+	return out.String()
+}
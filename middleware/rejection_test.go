@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newPressureOperatorStub returns operator rejecting all requests under the given memory pressure.
+func newPressureOperatorStub() *backpressureOperatorStub {
+	return &backpressureOperatorStub{
+		pressure: backpressure.Pressure{
+			Zone:                 stats.ZoneThrottling,
+			Utilization:          0.93,
+			ThrottlingPercentage: 40,
+		},
+	}
+}
+
+func rejectUnary(t *testing.T, cfg *RejectionConfig, options ...Option) error {
+	t.Helper()
+
+	return rejectUnaryWith(t, newPressureOperatorStub(), cfg, options...)
+}
+
+func rejectUnaryWith(
+	t *testing.T,
+	operator backpressure.Operator,
+	cfg *RejectionConfig,
+	options ...Option,
+) error {
+	t.Helper()
+
+	if cfg != nil {
+		require.NoError(t, cfg.Prepare())
+	}
+
+	options = append(options, WithConfig(&Config{GRPCServer: &ServerConfig{Rejection: cfg}}))
+
+	mw := NewMiddleware(logr.Discard(), operator, options...)
+
+	_, err := mw.GRPC().MakeUnaryServerInterceptor()(
+		context.Background(),
+		nil,
+		&grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"},
+		func(context.Context, any) (any, error) { return nil, nil },
+	)
+	require.Error(t, err)
+
+	return err
+}
+
+func TestRejection(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		err := rejectUnary(t, nil)
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+		require.Equal(t, throttledMessage, status.Convert(err).Message())
+		require.Empty(t, status.Convert(err).Details())
+	})
+
+	t.Run("memory pressure is requested on demand", func(t *testing.T) {
+		operator := newPressureOperatorStub()
+
+		err := rejectUnaryWith(t, operator, &RejectionConfig{Code: codes.Unavailable})
+		require.Equal(t, codes.Unavailable, status.Code(err))
+		require.Zero(t, operator.pressureCalls.Load())
+
+		err = rejectUnaryWith(t, operator, &RejectionConfig{Details: true})
+		require.Len(t, status.Convert(err).Details(), 1)
+		require.Equal(t, int64(1), operator.pressureCalls.Load())
+		require.Zero(t, operator.statsCalls.Load())
+	})
+
+	t.Run("per-method code", func(t *testing.T) {
+		err := rejectUnary(t, &RejectionConfig{
+			Code: codes.Unavailable,
+			Methods: []*MethodRejectionConfig{
+				{Pattern: "/other.Service/*", Code: codes.Aborted},
+				{Pattern: "/test.Service/*", Code: codes.ResourceExhausted},
+			},
+		})
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		err = rejectUnary(t, &RejectionConfig{Code: codes.Unavailable})
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("message template", func(t *testing.T) {
+		err := rejectUnary(t, &RejectionConfig{
+			Message: "{{.Method}} throttled: zone={{.Zone}}, throttling={{.ThrottlingPercentage}}%",
+		})
+		require.Equal(t, "/test.Service/Method throttled: zone=throttling, throttling=40%", status.Convert(err).Message())
+	})
+
+	t.Run("details", func(t *testing.T) {
+		err := rejectUnary(t, &RejectionConfig{Details: true})
+
+		details := status.Convert(err).Details()
+		require.Len(t, details, 1)
+
+		info, ok := details[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		require.Equal(t, RejectionReason, info.GetReason())
+		require.Equal(t, RejectionDomain, info.GetDomain())
+		require.Equal(t, map[string]string{
+			"zone":                  "throttling",
+			"utilization":           "0.93",
+			"throttling_percentage": "40",
+		}, info.GetMetadata())
+	})
+
+	t.Run("rejection func", func(t *testing.T) {
+		customErr := errors.New("custom")
+
+		var received *RejectionInfo
+
+		err := rejectUnary(t, &RejectionConfig{Code: codes.Unavailable}, WithRejectionFunc(
+			func(_ context.Context, info *RejectionInfo) error {
+				received = info
+
+				return customErr
+			}))
+		require.ErrorIs(t, err, customErr)
+		require.Equal(t, &RejectionInfo{
+			Method:               "/test.Service/Method",
+			Code:                 codes.Unavailable,
+			Message:              throttledMessage,
+			Zone:                 stats.ZoneThrottling,
+			Utilization:          0.93,
+			ThrottlingPercentage: 40,
+		}, received)
+	})
+
+	t.Run("rejection func falls back to policy", func(t *testing.T) {
+		err := rejectUnary(t, nil, WithRejectionFunc(func(context.Context, *RejectionInfo) error { return nil }))
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+}
+
+func TestRejectionConfig(t *testing.T) {
+	t.Run("codes from JSON", func(t *testing.T) {
+		c := &RejectionConfig{}
+		require.NoError(t, json.Unmarshal(
+			[]byte(`{"code": "UNAVAILABLE", "methods": [{"pattern": "/a.B/*", "code": "ABORTED"}]}`), c))
+		require.NoError(t, c.Prepare())
+		require.Equal(t, codes.Unavailable, c.Code)
+		require.Equal(t, codes.Aborted, c.Methods[0].Code)
+	})
+
+	t.Run("default code", func(t *testing.T) {
+		c := &RejectionConfig{}
+		require.NoError(t, c.Prepare())
+		require.Equal(t, codes.ResourceExhausted, c.Code)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		c := &RejectionConfig{Message: "{{.Method"}
+		require.Error(t, c.Prepare())
+	})
+
+	t.Run("invalid method code", func(t *testing.T) {
+		c := &MethodRejectionConfig{Pattern: "/a.B/*"}
+		require.Error(t, c.Prepare())
+	})
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/tap"
+)
+
+// NewMiddlewareStub creates middleware that never throttles anything, but still counts
+// requests per gRPC method and HTTP route. It's used when MemLimiter is disabled,
+// so that the application code doesn't depend on whether MemLimiter is enabled or not.
+// Only WithHTTPRouteFunc option is taken into account, the other ones are ignored.
+func NewMiddlewareStub(options ...Option) Middleware {
+	route := func(*http.Request) string { return defaultHTTPRoute }
+
+	for _, op := range options {
+		//nolint:gocritic
+		switch t := op.(type) {
+		case *httpRouteFuncOption:
+			route = t.val
+		}
+	}
+
+	return &middlewareStub{
+		grpc: &grpcStub{},
+		http: &httpStub{route: route},
+	}
+}
+
+var _ Middleware = (*middlewareStub)(nil)
+
+// middlewareStub is the pass-through implementation of the Middleware interface.
+type middlewareStub struct {
+	grpc *grpcStub
+	http *httpStub
+}
+
+func (m *middlewareStub) GRPC() GRPC { return m.grpc }
+
+func (m *middlewareStub) HTTP() HTTP { return m.http }
+
+func (m *middlewareStub) GetStats() (*stats.MiddlewareStats, error) {
+	out := &stats.MiddlewareStats{
+		GRPCServer: &stats.GRPCServerStats{
+			Methods: make(map[string]*stats.GRPCServerMethodStats),
+		},
+		HTTP: &stats.HTTPStats{
+			Routes: make(map[string]*stats.HTTPRouteStats),
+		},
+	}
+
+	m.grpc.methods.rangeCounters(func(method string, counters *admissionCounters) {
+		out.GRPCServer.Methods[method] = &stats.GRPCServerMethodStats{Requests: counters.requests.Load()}
+	})
+
+	m.http.routes.rangeCounters(func(route string, counters *admissionCounters) {
+		out.HTTP.Routes[route] = &stats.HTTPRouteStats{Requests: counters.requests.Load()}
+	})
+
+	return out, nil
+}
+
+// Quit does nothing, since stub has no background activity.
+func (m *middlewareStub) Quit() {}
+
+var _ GRPC = (*grpcStub)(nil)
+
+// grpcStub is the pass-through implementation of the GRPC interface.
+type grpcStub struct {
+	methods      admissionRegistry
+	knownMethods knownMethods
+}
+
+// admit counts the request; requests already counted by tap handle are not counted again.
+func (g *grpcStub) admit(ctx context.Context, method string) {
+	if ctx.Value(tapAdmittedKey{}) != nil {
+		return
+	}
+
+	g.methods.get(method).requests.Add(1)
+}
+
+// MakeUnaryServerInterceptor returns a unary server interceptor that admits all requests.
+func (g *grpcStub) MakeUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		method := unknownGRPCMethod
+		if info != nil && info.FullMethod != "" {
+			method = g.knownMethods.resolve(info.FullMethod)
+		}
+
+		g.admit(ctx, method)
+
+		return handler(ctx, req)
+	}
+}
+
+// MakeStreamServerInterceptor returns a stream server interceptor that admits all streams.
+func (g *grpcStub) MakeStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		method := unknownGRPCMethod
+		if info != nil && info.FullMethod != "" {
+			method = g.knownMethods.resolve(info.FullMethod)
+		}
+
+		g.admit(ss.Context(), method)
+
+		return handler(srv, ss)
+	}
+}
+
+// MakeTapHandle returns a tap handle that admits all streams.
+func (g *grpcStub) MakeTapHandle() tap.ServerInHandle {
+	return func(ctx context.Context, info *tap.Info) (context.Context, error) {
+		method := unknownGRPCMethod
+		if info != nil && info.FullMethodName != "" {
+			method = g.knownMethods.resolve(info.FullMethodName)
+		}
+
+		g.admit(ctx, method)
+
+		return context.WithValue(ctx, tapAdmittedKey{}, struct{}{}), nil
+	}
+}
+
+// SetServiceInfo provides the services registered in the server.
+func (g *grpcStub) SetServiceInfo(info map[string]grpc.ServiceInfo) { g.knownMethods.set(info) }
+
+// MakeUnaryClientInterceptor returns a unary client interceptor that never throttles requests.
+func (g *grpcStub) MakeUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// MakeStreamClientInterceptor returns a stream client interceptor that never throttles streams.
+func (g *grpcStub) MakeStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+var _ HTTP = (*httpStub)(nil)
+
+// httpStub is the pass-through implementation of the HTTP interface.
+type httpStub struct {
+	route  RouteFunc
+	routes admissionRegistry
+}
+
+// MakeHandler wraps handler, counting requests only.
+func (h *httpStub) MakeHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.routes.get(h.route(r)).requests.Add(1)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+)
+
+// throttleLogger aggregates throttled requests into periodic summaries and logs some of them individually,
+// so that logging doesn't flood log pipeline when many requests are throttled.
+// It is safe for concurrent use.
+type throttleLogger struct {
+	// message is the message of the individual log records.
+	message string
+	// summary is the message of the summaries.
+	summary string
+	// key is the name of the key carrying method (or route).
+	key string
+	// interval is the interval of summaries.
+	interval time.Duration
+	// limiter limits individual log records; nil if they are disabled.
+	limiter *rate.Limiter
+	// counts are the numbers of requests throttled since the latest summary [key - method or route].
+	counts map[string]uint64
+	// timer fires the next summary; nil if nothing has been throttled since the latest summary.
+	timer *time.Timer
+	// stopped is set when the logger is stopped, no summaries are scheduled after that.
+	stopped bool
+	// mutex protects counts, timer and stopped.
+	mutex  sync.Mutex
+	logger logr.Logger
+}
+
+// newThrottleLogger constructs throttleLogger; default settings are used if config has not been prepared.
+func newThrottleLogger(logger logr.Logger, cfg *ThrottleLoggingConfig, message, summary, key string) *throttleLogger {
+	out := &throttleLogger{
+		message:  message,
+		summary:  summary,
+		key:      key,
+		interval: cfg.SummaryInterval.Duration,
+		counts:   make(map[string]uint64),
+		logger:   logger,
+	}
+
+	if out.interval <= 0 {
+		out.interval = defaultThrottleLoggingSummaryInterval
+	}
+
+	if cfg.RequestRate > 0 {
+		out.limiter = rate.NewLimiter(rate.Limit(cfg.RequestRate), 1)
+	}
+
+	return out
+}
+
+// log registers throttled request. Individual records are written with the logger from the context if any.
+func (l *throttleLogger) log(ctx context.Context, name string) {
+	l.mutex.Lock()
+
+	if !l.stopped {
+		l.counts[name]++
+
+		if l.timer == nil {
+			l.timer = time.AfterFunc(l.interval, l.flush)
+		}
+	}
+
+	l.mutex.Unlock()
+
+	if l.limiter == nil || !l.limiter.Allow() {
+		return
+	}
+
+	logger, err := logr.FromContext(ctx)
+	if err != nil {
+		logger = l.logger
+	}
+
+	logger.Info(l.message, l.key, name)
+}
+
+// flush writes the summary of the requests throttled since the latest summary.
+func (l *throttleLogger) flush() {
+	l.mutex.Lock()
+
+	if l.stopped {
+		// the pending summary has been written by stop
+		l.mutex.Unlock()
+
+		return
+	}
+
+	counts := l.counts
+	l.counts = make(map[string]uint64)
+	l.timer = nil
+	l.mutex.Unlock()
+
+	l.write(counts)
+}
+
+// stop cancels the scheduled summary and writes the pending one immediately.
+func (l *throttleLogger) stop() {
+	l.mutex.Lock()
+
+	l.stopped = true
+
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+
+	counts := l.counts
+	l.counts = make(map[string]uint64)
+	l.mutex.Unlock()
+
+	l.write(counts)
+}
+
+// write logs the summary.
+func (l *throttleLogger) write(counts map[string]uint64) {
+	if len(counts) == 0 {
+		return
+	}
+
+	var total uint64
+	for _, count := range counts {
+		total += count
+	}
+
+	l.logger.Info(l.summary, "interval", l.interval.String(), "total", total, "throttled", counts)
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package middleware
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+	"github.com/stretchr/testify/require"
+)
+
+// testThrottleLoggingConfig logs every throttled request and never writes summaries within a test.
+func testThrottleLoggingConfig() *ThrottleLoggingConfig {
+	return &ThrottleLoggingConfig{
+		SummaryInterval: duration.Duration{Duration: time.Hour},
+		RequestRate:     1e6,
+	}
+}
+
+// lineCollector is a concurrency-safe collector of log lines.
+type lineCollector struct {
+	lines []string
+	mutex sync.Mutex
+}
+
+func (c *lineCollector) logger() logr.Logger {
+	return funcr.New(func(_, args string) {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		c.lines = append(c.lines, args)
+	}, funcr.Options{})
+}
+
+func (c *lineCollector) get() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]string(nil), c.lines...)
+}
+
+func TestThrottleLoggingConfig(t *testing.T) {
+	cfg := &ThrottleLoggingConfig{}
+	require.NoError(t, cfg.Prepare())
+	require.Equal(t, defaultThrottleLoggingSummaryInterval, cfg.SummaryInterval.Duration)
+	require.Zero(t, cfg.RequestRate)
+
+	require.Error(t, (&ThrottleLoggingConfig{RequestRate: -1}).Prepare())
+	require.Error(t, (&ThrottleLoggingConfig{SummaryInterval: duration.Duration{Duration: -time.Second}}).Prepare())
+}
+
+func TestThrottleLogger(t *testing.T) {
+	t.Run("summary", func(t *testing.T) {
+		collector := &lineCollector{}
+		cfg := &ThrottleLoggingConfig{SummaryInterval: duration.Duration{Duration: 50 * time.Millisecond}}
+		require.NoError(t, cfg.Prepare())
+
+		l := newThrottleLogger(collector.logger(), cfg, "throttled", "summary", "grpc_method")
+
+		for range 3 {
+			l.log(context.Background(), "/test.Service/A")
+		}
+
+		l.log(context.Background(), "/test.Service/B")
+
+		require.Eventually(t, func() bool { return len(collector.get()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+		// map keys are printed in random order
+		line := collector.get()[0]
+		require.Contains(t, line, `"level"=0 "msg"="summary" "interval"="50ms" "total"=4 "throttled"={`)
+		require.Contains(t, line, `"/test.Service/A"=3`)
+		require.Contains(t, line, `"/test.Service/B"=1`)
+
+		// per-request logs are disabled by default, and nothing is summarized without throttled requests
+		time.Sleep(100 * time.Millisecond)
+		require.Len(t, collector.get(), 1)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		collector := &lineCollector{}
+		cfg := &ThrottleLoggingConfig{SummaryInterval: duration.Duration{Duration: 50 * time.Millisecond}}
+		require.NoError(t, cfg.Prepare())
+
+		l := newThrottleLogger(collector.logger(), cfg, "throttled", "summary", "grpc_method")
+		l.log(context.Background(), "/test.Service/A")
+
+		// the pending summary is written immediately, and nothing is written after that
+		l.stop()
+		require.Equal(t,
+			[]string{`"level"=0 "msg"="summary" "interval"="50ms" "total"=1 "throttled"={"/test.Service/A"=1}`},
+			collector.get(),
+		)
+
+		l.log(context.Background(), "/test.Service/A")
+		time.Sleep(100 * time.Millisecond)
+		require.Len(t, collector.get(), 1)
+	})
+
+	t.Run("unprepared config", func(t *testing.T) {
+		l := newThrottleLogger(logr.Discard(), &ThrottleLoggingConfig{}, "throttled", "summary", "grpc_method")
+		require.Equal(t, defaultThrottleLoggingSummaryInterval, l.interval)
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		collector := &lineCollector{}
+		cfg := &ThrottleLoggingConfig{SummaryInterval: duration.Duration{Duration: time.Hour}, RequestRate: 0.001}
+		require.NoError(t, cfg.Prepare())
+
+		l := newThrottleLogger(logr.Discard(), cfg, "throttled", "summary", "http_route")
+
+		ctx := logr.NewContext(context.Background(), collector.logger())
+		for range 10 {
+			l.log(ctx, "/api")
+		}
+
+		require.Equal(t, []string{`"level"=0 "msg"="throttled" "http_route"="/api"`}, collector.get())
+	})
+}
@@ -8,6 +8,7 @@ package memlimiter
 
 import (
 	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/middleware"
 	"github.com/newcloudtechnologies/memlimiter/stats"
 )
 
@@ -24,6 +25,7 @@ func (b *backpressureOperatorOption) anchor() {}
 
 // WithBackpressureOperator allows client to provide customized backpressure.Operator;
 // that's especially useful when implementing backpressure logic on the application side.
+// Use backpressure.NewCompositeOperator to extend the default operator instead of replacing it.
 func WithBackpressureOperator(val backpressure.Operator) Option {
 	return &backpressureOperatorOption{val: val}
 }
@@ -39,3 +41,43 @@ func (s serviceStatsSubscriptionOption) anchor() {
 func WithServiceStatsSubscription(val stats.ServiceStatsSubscription) Option {
 	return &serviceStatsSubscriptionOption{val: val}
 }
+
+type middlewareOptionsOption struct {
+	val []middleware.Option
+}
+
+func (m *middlewareOptionsOption) anchor() {}
+
+// WithMiddlewareOptions passes additional options to the middleware constructor
+// (for instance, middleware.WithPriorityFunc or middleware.WithInFlightRegistry
+// when the in-flight registry is a part of the customized backpressure operator).
+func WithMiddlewareOptions(val ...middleware.Option) Option {
+	return &middlewareOptionsOption{val: val}
+}
+
+type expvarOption struct {
+	name string
+}
+
+func (e *expvarOption) anchor() {}
+
+// WithExpvar publishes MemLimiter state as expvar.Var with the given name (e.g. "memlimiter"),
+// so that it's available at /debug/vars. The value is a JSON snapshot of ExpvarState refreshed
+// after every controller update, so reading it never blocks MemLimiter. Services created later
+// with the same name reuse the variable. The option is ignored by the service stub.
+func WithExpvar(name string) Option {
+	return &expvarOption{name: name}
+}
+
+type auditSinkOption struct {
+	val backpressure.AuditSink
+}
+
+func (a *auditSinkOption) anchor() {}
+
+// WithAuditSink makes the default backpressure operator emit every control decision with explanation
+// into the sink (see backpressure.WithAuditSink); sampling is set with Config.Backpressure.Audit.
+// The option is ignored if operator is provided with WithBackpressureOperator.
+func WithAuditSink(sink backpressure.AuditSink) Option {
+	return &auditSinkOption{val: sink}
+}
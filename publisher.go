@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/backpressure"
+	"github.com/newcloudtechnologies/memlimiter/events"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+var _ backpressure.Operator = (*publishingOperator)(nil)
+
+// publishingOperator intercepts control parameters passed from controller to the backpressure operator
+// and publishes events describing MemLimiter state changes.
+type publishingOperator struct {
+	backpressure.Operator
+
+	bus *events.Bus
+	// lastControlParameters are the latest control parameters.
+	lastControlParameters *stats.ControlParameters
+	// lastZone is the latest memory budget utilization zone.
+	lastZone stats.Zone
+	// zoneKnown is set as soon as the first controller statistics is received.
+	zoneKnown bool
+	// observers are called after every update; they must not block.
+	observers []func(value *stats.ControlParameters)
+	// mutex protects the state.
+	mutex sync.Mutex
+}
+
+// newPublishingOperator wraps the operator.
+func newPublishingOperator(operator backpressure.Operator, bus *events.Bus) *publishingOperator {
+	return &publishingOperator{
+		Operator: operator,
+		bus:      bus,
+	}
+}
+
+// SetControlParameters passes control parameters to the wrapped operator and publishes events.
+func (p *publishingOperator) SetControlParameters(value *stats.ControlParameters) error {
+	err := p.Operator.SetControlParameters(value)
+
+	p.publishEvents(value)
+
+	for _, observer := range p.observers {
+		observer(value)
+	}
+
+	return err
+}
+
+// publishEvents publishes events describing the difference between the actual and the previous state.
+func (p *publishingOperator) publishEvents(value *stats.ControlParameters) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+
+	if p.lastControlParameters == nil || !value.EqualsTo(p.lastControlParameters) {
+		p.publish(now, events.KindControlParametersChanged, value, p.zoneOf(value))
+	}
+
+	p.lastControlParameters = value
+
+	if value.ControllerStats == nil || value.ControllerStats.MemoryBudget == nil {
+		return
+	}
+
+	zone := value.ControllerStats.MemoryBudget.Zone
+
+	if p.zoneKnown && zone == p.lastZone {
+		return
+	}
+
+	if p.zoneKnown {
+		p.publish(now, events.KindZoneLeft, value, p.lastZone)
+	}
+
+	p.publish(now, events.KindZoneEntered, value, zone)
+
+	if zone == stats.ZoneCritical {
+		p.publish(now, events.KindCritical, value, zone)
+	}
+
+	p.lastZone = zone
+	p.zoneKnown = true
+}
+
+// zoneOf returns the zone corresponding to control parameters or the latest known zone.
+func (p *publishingOperator) zoneOf(value *stats.ControlParameters) stats.Zone {
+	if value.ControllerStats != nil && value.ControllerStats.MemoryBudget != nil {
+		return value.ControllerStats.MemoryBudget.Zone
+	}
+
+	return p.lastZone
+}
+
+func (p *publishingOperator) publish(now time.Time, kind events.Kind, value *stats.ControlParameters, zone stats.Zone) {
+	p.bus.Publish(&events.Event{
+		Time:              now,
+		ControlParameters: value,
+		Kind:              kind,
+		Zone:              zone,
+	})
+}
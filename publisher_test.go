@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"testing"
+
+	"github.com/newcloudtechnologies/memlimiter/events"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+)
+
+func makeControlParameters(gogc int, throttling uint32, zone stats.Zone, utilization float64) *stats.ControlParameters {
+	return &stats.ControlParameters{
+		ControllerStats: &stats.ControllerStats{
+			MemoryBudget: &stats.MemoryBudgetStats{Zone: zone, Utilization: utilization},
+		},
+		GOGC:                 gogc,
+		ThrottlingPercentage: throttling,
+	}
+}
+
+func TestPublishingOperator(t *testing.T) {
+	bus := events.NewBus()
+	defer bus.Close()
+
+	sub := bus.Subscribe()
+
+	op := newPublishingOperator(&backpressureOperatorStub{}, bus)
+
+	// Initial control parameters are issued before controller gathers any statistics.
+	require.NoError(t, op.SetControlParameters(&stats.ControlParameters{GOGC: 100}))
+	require.NoError(t, op.SetControlParameters(makeControlParameters(100, 0, stats.ZoneGreen, 0.5)))
+	require.NoError(t, op.SetControlParameters(makeControlParameters(80, 0, stats.ZoneGOGC, 0.8)))
+	require.NoError(t, op.SetControlParameters(makeControlParameters(10, 99, stats.ZoneCritical, 1.1)))
+
+	expected := []struct {
+		kind events.Kind
+		zone stats.Zone
+	}{
+		{kind: events.KindControlParametersChanged, zone: stats.ZoneGreen},
+		{kind: events.KindZoneEntered, zone: stats.ZoneGreen},
+		{kind: events.KindControlParametersChanged, zone: stats.ZoneGOGC},
+		{kind: events.KindZoneLeft, zone: stats.ZoneGreen},
+		{kind: events.KindZoneEntered, zone: stats.ZoneGOGC},
+		{kind: events.KindControlParametersChanged, zone: stats.ZoneCritical},
+		{kind: events.KindZoneLeft, zone: stats.ZoneGOGC},
+		{kind: events.KindZoneEntered, zone: stats.ZoneCritical},
+		{kind: events.KindCritical, zone: stats.ZoneCritical},
+	}
+
+	for _, e := range expected {
+		event := <-sub.Events()
+		require.Equal(t, e.kind, event.Kind)
+		require.Equal(t, e.zone, event.Zone)
+	}
+
+	require.Empty(t, sub.Events())
+}
@@ -7,15 +7,18 @@
 package memlimiter
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
 	"runtime/debug"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/newcloudtechnologies/memlimiter/backpressure"
 	"github.com/newcloudtechnologies/memlimiter/controller"
 	"github.com/newcloudtechnologies/memlimiter/controller/nextgc"
+	"github.com/newcloudtechnologies/memlimiter/events"
 	"github.com/newcloudtechnologies/memlimiter/middleware"
 	"github.com/newcloudtechnologies/memlimiter/stats"
 	"github.com/newcloudtechnologies/memlimiter/utils/config/prepare"
@@ -28,6 +31,17 @@ type serviceImpl struct {
 	backpressureOperator backpressure.Operator
 	statsSubscription    stats.ServiceStatsSubscription
 	controller           controller.Controller
+	bus                  *events.Bus
+	admission            *admissionGate
+	// history keeps the latest control decisions for the debug handler.
+	history *controlHistory
+	// statsHistory keeps periodic statistics samples; nil if history is disabled.
+	statsHistory *statsHistory
+	cfg          *Config
+	// expvar is not nil if MemLimiter state is published with expvar.
+	expvar *expvarPublisher
+	// diagnostics is not nil if diagnostics capture is enabled.
+	diagnostics          *diagnosticsCapturer
 	restoreGoMemoryLimit bool
 	oldGoMemoryLimit     int64
 	logger               logr.Logger
@@ -35,6 +49,18 @@ type serviceImpl struct {
 
 func (s *serviceImpl) Middleware() middleware.Middleware { return s.middleware }
 
+func (s *serviceImpl) Subscribe(options ...events.SubscribeOption) events.Subscription {
+	return s.bus.Subscribe(options...)
+}
+
+func (s *serviceImpl) Admit(ctx context.Context) (func(), error) {
+	return s.admission.admit(ctx)
+}
+
+func (s *serviceImpl) WaitUntilBelow(ctx context.Context, level float64) error {
+	return s.admission.waitUntilBelow(ctx, level)
+}
+
 func (s *serviceImpl) GetStats() (*stats.MemLimiterStats, error) {
 	controllerStats, err := s.controller.GetStats()
 	if err != nil {
@@ -46,18 +72,73 @@ func (s *serviceImpl) GetStats() (*stats.MemLimiterStats, error) {
 		return nil, fmt.Errorf("backpressure tracker: %w", err)
 	}
 
+	middlewareStats, err := s.middleware.GetStats()
+	if err != nil {
+		return nil, fmt.Errorf("middleware tracker: %w", err)
+	}
+
 	return &stats.MemLimiterStats{
 		Controller:   controllerStats,
 		Backpressure: backpressureStats,
+		Middleware:   middlewareStats,
+		Admission:    s.admission.getStats(),
 	}, nil
 }
 
+func (s *serviceImpl) GetStatsHistory(from, to time.Time) ([]*StatsSample, error) {
+	if s.statsHistory == nil {
+		return nil, nil
+	}
+
+	return s.statsHistory.query(from, to), nil
+}
+
+func (s *serviceImpl) debugState(history time.Duration) (*DebugState, error) {
+	memLimiterStats, err := s.GetStats()
+	if err != nil {
+		return nil, err
+	}
+
+	decisions, samples := s.history.get()
+
+	out := &DebugState{
+		Config:    s.cfg,
+		Stats:     memLimiterStats,
+		Decisions: decisions,
+		Samples:   samples,
+	}
+
+	if history > 0 && s.statsHistory != nil {
+		out.History = s.statsHistory.query(time.Now().Add(-history), time.Time{})
+	}
+
+	return out, nil
+}
+
 func (s *serviceImpl) Quit() {
 	s.logger.Info("terminating MemLimiter service")
+
+	if s.statsHistory != nil {
+		s.statsHistory.quit()
+	}
+
 	s.controller.Quit()
 	s.statsSubscription.Quit()
+	s.middleware.Quit()
 	s.backpressureOperator.Quit()
 
+	if s.expvar != nil {
+		s.expvar.quit()
+	}
+
+	if s.diagnostics != nil {
+		s.diagnostics.quit()
+	}
+
+	if s.bus != nil {
+		s.bus.Close()
+	}
+
 	if s.restoreGoMemoryLimit {
 		debug.SetMemoryLimit(s.oldGoMemoryLimit)
 	}
@@ -69,6 +150,9 @@ func newServiceImpl(
 	cfg *Config,
 	statsSubscription stats.ServiceStatsSubscription,
 	backpressureOperator backpressure.Operator,
+	history *controlHistory,
+	expvarName string,
+	extraMiddlewareOptions ...middleware.Option,
 ) (Service, error) {
 	if err := prepare.Prepare(cfg); err != nil {
 		return nil, fmt.Errorf("prepare config: %w", err)
@@ -92,13 +176,53 @@ func newServiceImpl(
 		restoreGoMemoryLimit = true
 	}
 
+	var expvarPub *expvarPublisher
+
+	if expvarName != "" {
+		var err error
+
+		if expvarPub, err = newExpvarPublisher(expvarName); err != nil {
+			if restoreGoMemoryLimit {
+				debug.SetMemoryLimit(oldGoMemoryLimit)
+			}
+
+			return nil, fmt.Errorf("new expvar publisher: %w", err)
+		}
+	}
+
 	logger.Info("starting MemLimiter service")
 
+	bus := events.NewBus()
+
+	// Custom operators don't emit audit records, so the history stays empty.
+	if history == nil {
+		history = newControlHistory(cfg.Debug)
+	}
+
+	publishingOp := newPublishingOperator(backpressureOperator, bus)
+
+	if expvarPub != nil {
+		publishingOp.observers = append(publishingOp.observers, func(*stats.ControlParameters) { expvarPub.notify() })
+	}
+
+	var statsHist *statsHistory
+
+	if cfg.StatsHistory != nil {
+		statsHist = newStatsHistory(cfg.StatsHistory)
+	}
+
+	var capturer *diagnosticsCapturer
+
+	if cfg.Diagnostics != nil {
+		capturer = newDiagnosticsCapturer(cfg.Diagnostics)
+		publishingOp.observers = append(publishingOp.observers, capturer.observe)
+	}
+
 	c, err := nextgc.NewControllerFromConfig(
 		logger,
 		cfg.ControllerNextGC,
 		statsSubscription,
-		backpressureOperator,
+		publishingOp,
 	)
 	if err != nil {
 		if restoreGoMemoryLimit {
@@ -108,13 +232,57 @@ func newServiceImpl(
 		return nil, fmt.Errorf("new controller from config: %w", err)
 	}
 
-	return &serviceImpl{
-		middleware:           middleware.NewMiddleware(logger, backpressureOperator),
+	middlewareOptions := []middleware.Option{middleware.WithConfig(cfg.Middleware)}
+	if cfg.Shadow {
+		middlewareOptions = append(middlewareOptions, middleware.WithShadowMode())
+	}
+
+	middlewareOptions = append(middlewareOptions, extraMiddlewareOptions...)
+
+	utilization := func() (float64, error) {
+		controllerStats, err := c.GetStats()
+		if err != nil {
+			return 0, fmt.Errorf("controller stats: %w", err)
+		}
+
+		if controllerStats == nil || controllerStats.MemoryBudget == nil {
+			return 0, errors.New("memory budget is unknown")
+		}
+
+		return controllerStats.MemoryBudget.Utilization, nil
+	}
+
+	out := &serviceImpl{
+		admission:            newAdmissionGate(backpressureOperator, utilization, cfg.Admission, cfg.Shadow),
+		middleware:           middleware.NewMiddleware(logger, backpressureOperator, middlewareOptions...),
 		backpressureOperator: backpressureOperator,
 		statsSubscription:    statsSubscription,
 		controller:           c,
+		bus:                  bus,
 		restoreGoMemoryLimit: restoreGoMemoryLimit,
 		oldGoMemoryLimit:     oldGoMemoryLimit,
+		expvar:               expvarPub,
+		diagnostics:          capturer,
+		history:              history,
+		statsHistory:         statsHist,
+		cfg:                  cfg,
 		logger:               logger,
-	}, nil
+	}
+
+	var getHistory func(from, to time.Time) []*StatsSample
+
+	if statsHist != nil {
+		statsHist.start(logger, out.GetStats)
+		getHistory = statsHist.query
+	}
+
+	if expvarPub != nil {
+		expvarPub.start(logger, out.GetStats)
+	}
+
+	if capturer != nil {
+		capturer.start(logger, out.GetStats, getHistory)
+	}
+
+	return out, nil
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/newcloudtechnologies/memlimiter/backpressure"
 	"github.com/newcloudtechnologies/memlimiter/controller"
 	"github.com/newcloudtechnologies/memlimiter/controller/nextgc"
+	"github.com/newcloudtechnologies/memlimiter/middleware"
 	"github.com/newcloudtechnologies/memlimiter/stats"
 	"github.com/newcloudtechnologies/memlimiter/utils/config/bytes"
 	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
@@ -25,6 +26,7 @@ var (
 	_ controller.Controller          = (*controllerStub)(nil)
 	_ stats.ServiceStatsSubscription = (*serviceStatsSubscriptionStub)(nil)
 	_ backpressure.Operator          = (*backpressureOperatorStub)(nil)
+	_ middleware.Middleware          = (*middlewareStub)(nil)
 )
 
 type controllerStub struct {
@@ -39,6 +41,11 @@ type backpressureOperatorStub struct {
 	quitCalled bool
 }
 
+type middlewareStub struct {
+	middleware.Middleware
+	quitCalled bool
+}
+
 func (c *controllerStub) GetStats() (*stats.ControllerStats, error) {
 	return &stats.ControllerStats{}, nil
 }
@@ -59,17 +66,21 @@ func (b *backpressureOperatorStub) GetStats() (*stats.BackpressureStats, error)
 
 func (b *backpressureOperatorStub) Quit() { b.quitCalled = true }
 
+func (m *middlewareStub) Quit() { m.quitCalled = true }
+
 func TestServiceImplQuit(t *testing.T) {
 	logger := testr.New(t)
 
 	c := &controllerStub{}
 	ss := &serviceStatsSubscriptionStub{}
 	bp := &backpressureOperatorStub{}
+	mw := &middlewareStub{Middleware: middleware.NewMiddlewareStub()}
 
 	s := &serviceImpl{
 		controller:           c,
 		statsSubscription:    ss,
 		backpressureOperator: bp,
+		middleware:           mw,
 		logger:               logger,
 	}
 
@@ -78,6 +89,7 @@ func TestServiceImplQuit(t *testing.T) {
 	require.True(t, c.quitCalled)
 	require.True(t, ss.quitCalled)
 	require.True(t, bp.quitCalled)
+	require.True(t, mw.quitCalled)
 }
 
 func TestNewServiceImplGoMemoryLimitLifecycle(t *testing.T) {
@@ -111,6 +123,8 @@ func TestNewServiceImplGoMemoryLimitLifecycle(t *testing.T) {
 		cfg,
 		&serviceStatsSubscriptionStub{},
 		&backpressureOperatorStub{},
+		nil,
+		"",
 	)
 	require.NoError(t, err)
 
@@ -120,3 +134,41 @@ func TestNewServiceImplGoMemoryLimitLifecycle(t *testing.T) {
 
 	require.Equal(t, initialLimit, debug.SetMemoryLimit(-1))
 }
+
+func TestNewServiceImplStatsHistoryIsOptional(t *testing.T) {
+	cfg := &Config{
+		ControllerNextGC: &nextgc.ControllerConfig{
+			RSSLimit:             bytes.Bytes{Value: 1 << 30},
+			DangerZoneGOGC:       50,
+			DangerZoneThrottling: 90,
+			Period:               duration.Duration{Duration: time.Hour},
+			ComponentProportional: &nextgc.ComponentProportionalConfig{
+				Coefficient: 1,
+			},
+		},
+	}
+
+	service, err := newServiceImpl(
+		testr.New(t),
+		cfg,
+		&serviceStatsSubscriptionStub{},
+		&backpressureOperatorStub{},
+		nil,
+		"",
+	)
+	require.NoError(t, err)
+
+	defer service.Quit()
+
+	impl, ok := service.(*serviceImpl)
+	require.True(t, ok)
+	require.Nil(t, impl.statsHistory)
+
+	samples, err := service.GetStatsHistory(time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Empty(t, samples)
+
+	state, err := impl.debugState(time.Minute)
+	require.NoError(t, err)
+	require.Empty(t, state.History)
+}
@@ -7,8 +7,12 @@
 package memlimiter
 
 import (
+	"context"
+	"fmt"
 	"sync/atomic"
+	"time"
 
+	"github.com/newcloudtechnologies/memlimiter/events"
 	"github.com/newcloudtechnologies/memlimiter/middleware"
 	"github.com/newcloudtechnologies/memlimiter/stats"
 	"github.com/newcloudtechnologies/memlimiter/utils/breaker"
@@ -20,20 +24,27 @@ var _ Service = (*serviceStub)(nil)
 type serviceStub struct {
 	latestStats       atomic.Value
 	statsSubscription stats.ServiceStatsSubscription
+	middleware        middleware.Middleware
 	breaker           *breaker.Breaker
+	// bus never publishes anything, it only helps to keep subscriptions consistent.
+	bus *events.Bus
 }
 
 // newServiceStub constructs a new service stub.
-func newServiceStub(statsSubscription stats.ServiceStatsSubscription) Service {
+func newServiceStub(statsSubscription stats.ServiceStatsSubscription, middlewareOptions ...middleware.Option) Service {
 	if statsSubscription == nil {
 		return &serviceStub{
-			breaker: breaker.NewBreaker(),
+			middleware: middleware.NewMiddlewareStub(middlewareOptions...),
+			breaker:    breaker.NewBreaker(),
+			bus:        events.NewBus(),
 		}
 	}
 
 	out := &serviceStub{
 		statsSubscription: statsSubscription,
+		middleware:        middleware.NewMiddlewareStub(middlewareOptions...),
 		breaker:           breaker.NewBreakerWithInitValue(1),
+		bus:               events.NewBus(),
 	}
 
 	go out.loop()
@@ -41,37 +52,68 @@ func newServiceStub(statsSubscription stats.ServiceStatsSubscription) Service {
 	return out
 }
 
-// Middleware returns the middleware.
-func (s *serviceStub) Middleware() middleware.Middleware {
-	// TODO: return stub
+// Middleware returns the pass-through middleware.
+func (s *serviceStub) Middleware() middleware.Middleware { return s.middleware }
+
+// Subscribe creates a subscription that receives no events.
+func (s *serviceStub) Subscribe(options ...events.SubscribeOption) events.Subscription {
+	return s.bus.Subscribe(options...)
+}
+
+// Admit always admits the work.
+func (s *serviceStub) Admit(_ context.Context) (func(), error) {
+	return func() {}, nil
+}
+
+// WaitUntilBelow never waits.
+func (s *serviceStub) WaitUntilBelow(_ context.Context, _ float64) error {
 	return nil
 }
 
+// debugState returns the statistics only, since the stub makes no control decisions.
+func (s *serviceStub) debugState(_ time.Duration) (*DebugState, error) {
+	memLimiterStats, err := s.GetStats()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DebugState{Stats: memLimiterStats}, nil
+}
+
 // Quit terminates the service stub gracefully.
 func (s *serviceStub) Quit() {
 	s.breaker.Shutdown()
 	s.statsSubscription.Quit()
+	s.middleware.Quit()
+	s.bus.Close()
 }
 
-// GetStats returns the current stats.
+// GetStatsHistory returns nothing, since the stub keeps no history.
+func (s *serviceStub) GetStatsHistory(_, _ time.Time) ([]*StatsSample, error) {
+	return nil, nil
+}
+
+// GetStats returns the current stats. Middleware stats are available from the very beginning,
+// while RSS is reported once the first service stats record is received (it's zero until then).
 func (s *serviceStub) GetStats() (*stats.MemLimiterStats, error) {
+	middlewareStats, err := s.middleware.GetStats()
+	if err != nil {
+		return nil, fmt.Errorf("middleware stats: %w", err)
+	}
+
+	out := &stats.MemLimiterStats{
+		Controller: &stats.ControllerStats{
+			MemoryBudget: &stats.MemoryBudgetStats{},
+		},
+		Middleware: middlewareStats,
+	}
+
 	if val := s.latestStats.Load(); val != nil {
 		//nolint:forcetypeassert
-		ss := val.(stats.ServiceStats)
-
-		out := &stats.MemLimiterStats{
-			Controller: &stats.ControllerStats{
-				MemoryBudget: &stats.MemoryBudgetStats{
-					RSSActual: ss.RSS(),
-				},
-			},
-		}
-
-		return out, nil
+		out.Controller.MemoryBudget.RSSActual = val.(stats.ServiceStats).RSS()
 	}
 
-	//nolint:nilnil // This is a stub.
-	return nil, nil
+	return out, nil
 }
 
 // loop is the main loop of the service stub.
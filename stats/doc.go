@@ -6,4 +6,9 @@
 
 // Package stats contains various data types describing service statistics
 // MemLimiter relies on, as well as its own statistics.
+//
+// MemLimiterStats has stable JSON representation: fields are named in snake_case,
+// absent sections are omitted, zones are encoded by name, durations - in nanoseconds,
+// timestamps - in RFC 3339 format. The representation is described by JSON Schema
+// (see JSONSchema), its version is reported in schema_version field.
 package stats
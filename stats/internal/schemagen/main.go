@@ -0,0 +1,279 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+// Command schemagen generates JSON Schema of stats.MemLimiterStats.
+// Types are discovered with reflection, descriptions are taken from the doc comments of the stats package.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory with the stats package source code")
+	out := flag.String("out", "schema.json", "output file")
+
+	flag.Parse()
+
+	data, err := generate(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	//nolint:gosec // The schema is a public document.
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// generate renders the schema.
+func generate(dir string) ([]byte, error) {
+	docs, err := parseDocs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("parse docs: %w", err)
+	}
+
+	g := &generator{docs: docs, defs: make(map[string]any)}
+
+	root, err := g.schemaOf(reflect.TypeFor[stats.MemLimiterStats]())
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "MemLimiterStats",
+		"description": docs.types["MemLimiterStats"],
+		"$defs":       g.defs,
+	}
+
+	for key, value := range root {
+		out[key] = value
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal schema: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// docs are the doc comments of the stats package.
+type docs struct {
+	// types - type descriptions [key - type name].
+	types map[string]string
+	// fields - field descriptions [key - type name, field name].
+	fields map[string]map[string]string
+}
+
+func parseDocs(dir string) (*docs, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, fmt.Errorf("glob: %w", err)
+	}
+
+	out := &docs{types: make(map[string]string), fields: make(map[string]map[string]string)}
+	fset := token.NewFileSet()
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		parsed, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("parse file '%s': %w", file, err)
+		}
+
+		for _, decl := range parsed.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				//nolint:forcetypeassert // Type declarations contain only type specs.
+				typeSpec := spec.(*ast.TypeSpec)
+				out.types[typeSpec.Name.Name] = docText(genDecl.Doc)
+
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+
+				fields := make(map[string]string)
+
+				for _, field := range structType.Fields.List {
+					for _, name := range field.Names {
+						fields[name.Name] = docText(field.Doc)
+					}
+				}
+
+				out.fields[typeSpec.Name.Name] = fields
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// docText joins comment lines into a single line.
+func docText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
+
+// generator collects type definitions.
+type generator struct {
+	docs *docs
+	// defs - type definitions [key - type name].
+	defs map[string]any
+}
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+	zoneType     = reflect.TypeFor[stats.Zone]()
+)
+
+//nolint:exhaustive // Unsupported kinds are reported as error.
+func (g *generator) schemaOf(t reflect.Type) (map[string]any, error) {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case durationType:
+		g.defs["Duration"] = map[string]any{"type": "integer", "description": "Duration in nanoseconds."}
+
+		return ref("Duration"), nil
+	case zoneType:
+		names := make([]string, 0, len(stats.Zones()))
+		for _, zone := range stats.Zones() {
+			names = append(names, zone.String())
+		}
+
+		g.defs["Zone"] = map[string]any{"type": "string", "enum": names, "description": g.docs.types["Zone"]}
+
+		return ref("Zone"), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaOf(t.Elem())
+	case reflect.Struct:
+		return g.structSchema(t)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %v", t.Key())
+		}
+
+		items, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]any{"type": "object", "additionalProperties": items}, nil
+	case reflect.Slice:
+		items, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %v", t)
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) (map[string]any, error) {
+	if t.Name() == "" {
+		return nil, errors.New("anonymous structs are not supported")
+	}
+
+	if _, ok := g.defs[t.Name()]; ok {
+		return ref(t.Name()), nil
+	}
+
+	var required []string
+
+	properties := make(map[string]any)
+	def := map[string]any{
+		"type":        "object",
+		"description": g.docs.types[t.Name()],
+		"properties":  properties,
+	}
+
+	// register definition before traversing fields to support recursive types
+	g.defs[t.Name()] = def
+
+	for field := range t.Fields() {
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return nil, fmt.Errorf("field %s.%s has no JSON name", t.Name(), field.Name)
+		}
+
+		property, err := g.schemaOf(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+		}
+
+		if description := g.docs.fields[t.Name()][field.Name]; description != "" {
+			property["description"] = description
+		}
+
+		if t == reflect.TypeFor[stats.MemLimiterStats]() && name == "schema_version" {
+			property["const"] = stats.SchemaVersion
+		}
+
+		properties[name] = property
+
+		if options != "omitempty" {
+			required = append(required, name)
+		}
+	}
+
+	if len(required) > 0 {
+		def["required"] = required
+	}
+
+	return ref(t.Name()), nil
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/$defs/" + name}
+}
@@ -0,0 +1,20 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaIsUpToDate(t *testing.T) {
+	data, err := generate("../..")
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(stats.JSONSchema()), "run 'go generate ./stats/...'")
+}
@@ -7,83 +7,396 @@
 package stats
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"time"
 )
 
+// SchemaVersion - version of the JSON representation of MemLimiterStats (see schema.json).
+// It is incremented whenever a field is renamed, removed or changes its meaning.
+const SchemaVersion = 1
+
 // MemLimiterStats - top-level MemLimiter statistics data type.
 type MemLimiterStats struct {
+	// SchemaVersion - version of the JSON representation; it's filled with SchemaVersion on encoding if empty.
+	SchemaVersion int `json:"schema_version"`
 	// ControllerStats - memory budget controller statistics
-	Controller *ControllerStats
+	Controller *ControllerStats `json:"controller,omitempty"`
 	// Backpressure - backpressure subsystem statistics
-	Backpressure *BackpressureStats
+	Backpressure *BackpressureStats `json:"backpressure,omitempty"`
+	// Middleware - middleware statistics
+	Middleware *MiddlewareStats `json:"middleware,omitempty"`
+	// Admission - transport-neutral admission gate statistics
+	Admission *AdmissionStats `json:"admission,omitempty"`
+}
+
+// memLimiterStatsJSON is used to encode MemLimiterStats avoiding MarshalJSON recursion.
+type memLimiterStatsJSON MemLimiterStats
+
+// MarshalJSON encodes statistics with the schema version.
+func (s MemLimiterStats) MarshalJSON() ([]byte, error) {
+	out := memLimiterStatsJSON(s)
+	if out.SchemaVersion == 0 {
+		out.SchemaVersion = SchemaVersion
+	}
+
+	return json.Marshal(&out)
+}
+
+// AdmissionStats - transport-neutral admission gate statistics.
+type AdmissionStats struct {
+	// Admitted - total number of admitted units of work.
+	Admitted uint64 `json:"admitted"`
+	// Throttled - total number of refused admission attempts.
+	Throttled uint64 `json:"throttled"`
+	// Waiting - number of callers waiting for admission or for utilization decrease right now.
+	Waiting int64 `json:"waiting"`
+	// InFlight - number of admitted units of work that have not been released yet.
+	InFlight int64 `json:"in_flight"`
+	// ShadowWaits - number of waits for utilization decrease that would have blocked in shadow (dry-run) mode.
+	ShadowWaits uint64 `json:"shadow_waits,omitempty"`
 }
 
 // ControllerStats - memory budget controller tracker.
 type ControllerStats struct {
 	// MemoryBudget - common memory budget information
-	MemoryBudget *MemoryBudgetStats
+	MemoryBudget *MemoryBudgetStats `json:"memory_budget,omitempty"`
 	// NextGC - NextGC-aware controller statistics
-	NextGC *ControllerNextGCStats
+	NextGC *ControllerNextGCStats `json:"next_gc,omitempty"`
 }
 
 // MemoryBudgetStats - memory budget tracker.
 type MemoryBudgetStats struct {
 	// SpecialConsumers - specialized memory consumers (like CGO) statistics.
-	SpecialConsumers *SpecialConsumersStats
+	SpecialConsumers *SpecialConsumersStats `json:"special_consumers,omitempty"`
 	// RSSActual - physical memory (RSS) current consumption [bytes].
-	RSSActual uint64
+	RSSActual uint64 `json:"rss_actual"`
 	// RSSLimit - physical memory (RSS) consumption limit [bytes].
-	RSSLimit uint64
+	RSSLimit uint64 `json:"rss_limit"`
 	// GoAllocLimit - allocation limit for Go Runtime (with the except of CGO) [bytes].
-	GoAllocLimit uint64
+	GoAllocLimit uint64 `json:"go_alloc_limit"`
 	// Utilization - memory budget utilization ratio
 	// (for example, 1.0 means 100%; definition depends on controller implementation).
-	Utilization float64
+	Utilization float64 `json:"utilization"`
+	// Zone - memory budget utilization zone.
+	Zone Zone `json:"zone"`
 }
 
 // SpecialConsumersStats - specialized memory consumers statistics.
 type SpecialConsumersStats struct {
 	// Go - Go runtime managed consumers.
-	Go map[string]uint64
+	Go map[string]uint64 `json:"go,omitempty"`
 	// Cgo - consumers residing beyond the Cgo border.
-	Cgo map[string]uint64
+	Cgo map[string]uint64 `json:"cgo,omitempty"`
 }
 
 // ControllerNextGCStats - NextGC-aware controller statistics.
 type ControllerNextGCStats struct {
 	// P - proportional component's output
-	P float64
+	P float64 `json:"p"`
 	// Output - final output
-	Output float64
+	Output float64 `json:"output"`
 }
 
 // BackpressureStats - backpressure subsystem statistics.
 type BackpressureStats struct {
 	// Throttling - throttling subsystem statistics.
-	Throttling *ThrottlingStats
+	Throttling *ThrottlingStats `json:"throttling,omitempty"`
 	// ControlParameters - control signal received from controller.
-	ControlParameters *ControlParameters
+	ControlParameters *ControlParameters `json:"control_parameters,omitempty"`
+	// Shrinking - statistics of the components releasing memory on demand.
+	Shrinking *ShrinkingStats `json:"shrinking,omitempty"`
+	// Shadow - shadow (dry-run) mode statistics; nil if shadow mode is disabled.
+	Shadow *ShadowStats `json:"shadow,omitempty"`
+	// Actuators - statistics of the actuators applying control parameters.
+	Actuators *ActuatorsStats `json:"actuators,omitempty"`
+	// Cancellation - in-flight requests cancellation statistics.
+	Cancellation *CancellationStats `json:"cancellation,omitempty"`
+	// Health - health reporting statistics.
+	Health *HealthStats `json:"health,omitempty"`
+	// Connections - connection-level admission statistics.
+	Connections *ConnectionsStats `json:"connections,omitempty"`
+}
+
+// Merge fills the sections missing in s with the sections of other, the sections present in s are kept.
+// All the sections are merged, so the new ones need no special handling.
+func (s *BackpressureStats) Merge(other *BackpressureStats) {
+	if other == nil {
+		return
+	}
+
+	dst, src := reflect.ValueOf(s).Elem(), reflect.ValueOf(other).Elem()
+
+	for i := range dst.NumField() {
+		if field := dst.Field(i); field.Kind() == reflect.Pointer && field.IsNil() {
+			field.Set(src.Field(i))
+		}
+	}
+}
+
+// ConnectionsStats - connection-level admission statistics.
+type ConnectionsStats struct {
+	// Open - number of open connections.
+	Open int64 `json:"open"`
+	// Accepted - total number of admitted connections.
+	Accepted uint64 `json:"accepted"`
+	// Rejected - total number of connections closed immediately after accepting.
+	Rejected uint64 `json:"rejected"`
+	// Paused - number of times Accept has been paused.
+	Paused uint64 `json:"paused"`
+	// PauseDuration - total time Accept has been paused (nanoseconds in JSON).
+	PauseDuration time.Duration `json:"pause_duration"`
+	// ShadowRejected - number of connections that would have been rejected in shadow (dry-run) mode.
+	ShadowRejected uint64 `json:"shadow_rejected,omitempty"`
+	// ShadowPaused - number of connections that would have been held by paused Accept in shadow (dry-run) mode.
+	ShadowPaused uint64 `json:"shadow_paused,omitempty"`
+}
+
+// HealthStats - health reporting statistics.
+type HealthStats struct {
+	// Serving - actual health status.
+	Serving bool `json:"serving"`
+	// Transitions - number of health status changes.
+	Transitions uint64 `json:"transitions"`
+	// LastTransition - time of the latest health status change.
+	LastTransition time.Time `json:"last_transition"`
+	// ShadowTransitions - number of health status changes that would have happened in shadow (dry-run) mode.
+	ShadowTransitions uint64 `json:"shadow_transitions,omitempty"`
+}
+
+// CancellationStats - in-flight requests cancellation statistics.
+type CancellationStats struct {
+	// InFlight - number of requests being served right now.
+	InFlight int `json:"in_flight"`
+	// Cancelled - total number of requests cancelled due to critical memory pressure.
+	Cancelled uint64 `json:"cancelled"`
+	// ShadowCancelled - total number of requests that would have been cancelled in shadow (dry-run) mode.
+	ShadowCancelled uint64 `json:"shadow_cancelled,omitempty"`
+}
+
+// ActuatorsStats - statistics of the actuators applying control parameters.
+type ActuatorsStats struct {
+	// GOGC - GOGC actuator statistics.
+	GOGC *ActuatorStats `json:"gogc,omitempty"`
+	// Throttling - request throttling actuator statistics.
+	Throttling *ActuatorStats `json:"throttling,omitempty"`
+}
+
+// ActuatorStats - statistics of the actuator applying a single control parameter.
+type ActuatorStats struct {
+	// Enabled - whether the actuator is enabled.
+	Enabled bool `json:"enabled"`
+	// Value - currently applied value (it may differ from the control parameter because of
+	// bounds, rate of change limit and deadband).
+	Value int `json:"value"`
+	// Updates - number of updates that changed the applied value.
+	Updates uint64 `json:"updates"`
+	// Suppressed - number of changes ignored because of deadband.
+	Suppressed uint64 `json:"suppressed"`
+}
+
+// ShadowStats - shadow (dry-run) mode statistics describing what would have happened
+// if MemLimiter had been enforcing its decisions.
+type ShadowStats struct {
+	// ZoneDurations - time spent in each memory budget utilization zone [key - zone name]
+	// (nanoseconds in JSON).
+	ZoneDurations map[string]time.Duration `json:"zone_durations,omitempty"`
+	// GOGC - GOGC value that would have been applied.
+	GOGC int `json:"gogc"`
+}
+
+// ShrinkingStats - statistics of the components releasing memory on demand.
+type ShrinkingStats struct {
+	// Components - per-component statistics [key - component name].
+	Components map[string]*ShrinkableStats `json:"components,omitempty"`
+}
+
+// ShrinkableStats - statistics of a component releasing memory on demand.
+type ShrinkableStats struct {
+	// LastCall - the moment of the latest call.
+	LastCall time.Time `json:"last_call"`
+	// Calls - number of calls.
+	Calls uint64 `json:"calls"`
+	// Failures - number of calls finished with error.
+	Failures uint64 `json:"failures"`
+	// Timeouts - number of calls that did not fit into the timeout.
+	Timeouts uint64 `json:"timeouts"`
+	// FreedBytes - total amount of memory released by the component [bytes].
+	FreedBytes uint64 `json:"freed_bytes"`
+	// LastFreedBytes - amount of memory released by the component during the latest call [bytes].
+	LastFreedBytes uint64 `json:"last_freed_bytes"`
 }
 
 // ThrottlingStats - throttling subsystem statistics.
 type ThrottlingStats struct {
+	// Windows - statistics over the rolling windows of different length (sorted by window length).
+	Windows []*ThrottlingWindowStats `json:"windows,omitempty"`
 	// Passed - number of allowed requests.
-	Passed uint64
+	Passed uint64 `json:"passed"`
 	// Throttled - number of throttled requests.
-	Throttled uint64
+	Throttled uint64 `json:"throttled"`
 	// Total - total number of received requests (Passed + Throttled)
-	Total uint64
+	Total uint64 `json:"total"`
+}
+
+// ThrottlingWindowStats - throttling subsystem statistics over the rolling window.
+type ThrottlingWindowStats struct {
+	// Window - window length (nanoseconds in JSON).
+	Window time.Duration `json:"window"`
+	// Passed - number of requests allowed within the window.
+	Passed uint64 `json:"passed"`
+	// Throttled - number of requests throttled within the window.
+	Throttled uint64 `json:"throttled"`
+	// PassedRate - allowed requests per second.
+	PassedRate float64 `json:"passed_rate"`
+	// ThrottledRate - throttled requests per second.
+	ThrottledRate float64 `json:"throttled_rate"`
+	// ThrottledShare - share of throttled requests within the window (in range [0; 1]).
+	ThrottledShare float64 `json:"throttled_share"`
+}
+
+// MiddlewareStats - middleware statistics.
+type MiddlewareStats struct {
+	// GRPCServer - gRPC server-side admission statistics.
+	GRPCServer *GRPCServerStats `json:"grpc_server,omitempty"`
+	// GRPCClient - gRPC client-side adaptive throttling statistics.
+	GRPCClient *GRPCClientStats `json:"grpc_client,omitempty"`
+	// GRPCStream - gRPC per-message stream admission statistics; nil if per-message admission is disabled.
+	GRPCStream *GRPCStreamStats `json:"grpc_stream,omitempty"`
+	// HTTP - net/http middleware statistics.
+	HTTP *HTTPStats `json:"http,omitempty"`
+	// Cancelled - number of requests cancelled due to critical memory pressure [key - method].
+	Cancelled map[string]uint64 `json:"cancelled,omitempty"`
+	// ShadowThrottled - number of requests that would have been throttled in shadow (dry-run) mode
+	// [key - method]; nil if shadow mode is disabled.
+	ShadowThrottled map[string]uint64 `json:"shadow_throttled,omitempty"`
+}
+
+// GRPCServerStats - gRPC server-side admission statistics (interceptors and tap handle).
+type GRPCServerStats struct {
+	// Methods - per-method statistics [key - method].
+	Methods map[string]*GRPCServerMethodStats `json:"methods,omitempty"`
+}
+
+// GRPCServerMethodStats - gRPC server-side admission statistics for a particular method.
+type GRPCServerMethodStats struct {
+	// Requests - total number of requests.
+	Requests uint64 `json:"requests"`
+	// Throttled - number of requests rejected due to memory pressure.
+	Throttled uint64 `json:"throttled"`
+	// Exempted - number of requests served bypassing admission.
+	Exempted uint64 `json:"exempted"`
+}
+
+// GRPCClientStats - gRPC client-side adaptive throttling statistics.
+type GRPCClientStats struct {
+	// Targets - per-target statistics [key - target of the client connection].
+	Targets map[string]*GRPCClientTargetStats `json:"targets,omitempty"`
+	// Requests - total number of requests issued by the client, including the locally rejected ones.
+	Requests uint64 `json:"requests"`
+	// Accepted - number of requests accepted by the servers.
+	Accepted uint64 `json:"accepted"`
+	// RejectedLocally - number of requests rejected by the client without sending them.
+	RejectedLocally uint64 `json:"rejected_locally"`
+	// RejectedRemotely - number of requests rejected by the servers with ResourceExhausted code.
+	RejectedRemotely uint64 `json:"rejected_remotely"`
+	// ShadowRejectedLocally - number of requests that would have been rejected by the client
+	// in shadow (dry-run) mode; such requests are sent anyway.
+	ShadowRejectedLocally uint64 `json:"shadow_rejected_locally,omitempty"`
+}
+
+// GRPCClientTargetStats - gRPC client-side adaptive throttling statistics for a particular target.
+type GRPCClientTargetStats struct {
+	// Requests - number of requests issued within the throttling window.
+	Requests uint64 `json:"requests"`
+	// Accepts - number of requests accepted by the server within the throttling window.
+	Accepts uint64 `json:"accepts"`
+	// RejectionProbability - probability of the local rejection of the next request (in range [0; 1]).
+	RejectionProbability float64 `json:"rejection_probability"`
 }
 
-// ControlParameters - вектор управляющих сигналов для системы.
+// HTTPStats - net/http middleware statistics.
+type HTTPStats struct {
+	// Routes - per-route statistics [key - route].
+	Routes map[string]*HTTPRouteStats `json:"routes,omitempty"`
+}
+
+// HTTPRouteStats - net/http middleware statistics for a particular route.
+type HTTPRouteStats struct {
+	// Requests - total number of requests.
+	Requests uint64 `json:"requests"`
+	// Throttled - number of requests rejected due to memory pressure.
+	Throttled uint64 `json:"throttled"`
+	// Exempted - number of requests served bypassing admission.
+	Exempted uint64 `json:"exempted"`
+}
+
+// GRPCStreamStats - gRPC per-message stream admission statistics.
+type GRPCStreamStats struct {
+	// Methods - per-method statistics [key - method].
+	Methods map[string]*GRPCStreamMethodStats `json:"methods,omitempty"`
+}
+
+// GRPCStreamMethodStats - gRPC per-message stream admission statistics for a particular method.
+type GRPCStreamMethodStats struct {
+	// Streams - total number of streams opened.
+	Streams uint64 `json:"streams"`
+	// ActiveStreams - number of streams being served right now.
+	ActiveStreams int64 `json:"active_streams"`
+	// Messages - number of messages received.
+	Messages uint64 `json:"messages"`
+	// BytesReceived - total size of the messages received.
+	BytesReceived uint64 `json:"bytes_received"`
+	// Delayed - number of messages that have been delayed before receiving.
+	Delayed uint64 `json:"delayed"`
+	// Rejected - number of streams terminated because of the throttled message.
+	Rejected uint64 `json:"rejected"`
+}
+
+// ControlParameters - vector of control signals for the system.
 type ControlParameters struct {
 	// ControllerStats - internal telemetry that may be useful for
 	// implementation of application-specific backpressure actors.
-	ControllerStats *ControllerStats
+	ControllerStats *ControllerStats `json:"controller_stats,omitempty"`
 	// GOGC - value that will be used as a parameter for debug.SetGCPercent
-	GOGC int
+	GOGC int `json:"gogc"`
 	// ThrottlingPercentage - percentage of requests that must be throttled on the middleware level (in range [0; 100])
-	ThrottlingPercentage uint32
+	ThrottlingPercentage uint32 `json:"throttling_percentage"`
+	// Explanation - details of how the control parameters have been derived;
+	// nil if controller doesn't provide them.
+	Explanation *ControlExplanation `json:"explanation,omitempty"`
+}
+
+// ControlRule - the rule that determined the value of a control parameter.
+type ControlRule string
+
+const (
+	// ControlRuleBelowDangerZone - utilization is below the danger zone of the parameter,
+	// so the parameter has the default value.
+	ControlRuleBelowDangerZone ControlRule = "below_danger_zone"
+	// ControlRuleOutput - the parameter is derived from the controller output.
+	ControlRuleOutput ControlRule = "controller_output"
+	// ControlRuleMinGOGC - GOGC derived from the controller output has been raised to the minimal value.
+	ControlRuleMinGOGC ControlRule = "min_gogc"
+)
+
+// ControlExplanation - details of how the control parameters have been derived from memory budget utilization.
+type ControlExplanation struct {
+	// CgoTotal - total memory consumed beyond the Cgo border [bytes].
+	CgoTotal uint64 `json:"cgo_total"`
+	// BudgetExhausted - Cgo consumers have exhausted the RSS limit, so utilization is forced above 1.
+	BudgetExhausted bool `json:"budget_exhausted"`
+	// OutputSaturated - controller output has been clamped to the allowed range.
+	OutputSaturated bool `json:"output_saturated"`
+	// DangerZoneGOGC - utilization threshold enabling GOGC tuning [percents].
+	DangerZoneGOGC uint32 `json:"danger_zone_gogc"`
+	// DangerZoneThrottling - utilization threshold enabling throttling [percents].
+	DangerZoneThrottling uint32 `json:"danger_zone_throttling"`
+	// GOGCRule - the rule that determined GOGC.
+	GOGCRule ControlRule `json:"gogc_rule"`
+	// ThrottlingRule - the rule that determined throttling percentage.
+	ThrottlingRule ControlRule `json:"throttling_rule"`
 }
 
 func (cp *ControlParameters) String() string {
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemLimiterStatsJSON(t *testing.T) {
+	in := &MemLimiterStats{
+		Controller: &ControllerStats{
+			MemoryBudget: &MemoryBudgetStats{
+				SpecialConsumers: &SpecialConsumersStats{Cgo: map[string]uint64{"sqlite": 1024}},
+				RSSActual:        900,
+				RSSLimit:         1000,
+				Utilization:      0.9,
+				Zone:             ZoneThrottling,
+			},
+			NextGC: &ControllerNextGCStats{P: 0.5, Output: 0.5},
+		},
+		Backpressure: &BackpressureStats{
+			Throttling: &ThrottlingStats{
+				Windows: []*ThrottlingWindowStats{{Window: time.Second, Passed: 7, Throttled: 3, ThrottledShare: 0.3}},
+				Passed:  7, Throttled: 3, Total: 10,
+			},
+			Health: &HealthStats{Serving: true, LastTransition: time.Unix(1000, 0).UTC()},
+		},
+	}
+
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(data, &raw))
+	require.Equal(t, map[string]any{
+		"schema_version": float64(SchemaVersion),
+		"controller": map[string]any{
+			"memory_budget": map[string]any{
+				"special_consumers": map[string]any{"cgo": map[string]any{"sqlite": float64(1024)}},
+				"rss_actual":        float64(900),
+				"rss_limit":         float64(1000),
+				"go_alloc_limit":    float64(0),
+				"utilization":       0.9,
+				"zone":              "throttling",
+			},
+			"next_gc": map[string]any{"p": 0.5, "output": 0.5},
+		},
+		"backpressure": map[string]any{
+			"throttling": map[string]any{
+				"windows": []any{map[string]any{
+					"window":          float64(time.Second),
+					"passed":          float64(7),
+					"throttled":       float64(3),
+					"passed_rate":     float64(0),
+					"throttled_rate":  float64(0),
+					"throttled_share": 0.3,
+				}},
+				"passed":    float64(7),
+				"throttled": float64(3),
+				"total":     float64(10),
+			},
+			"health": map[string]any{
+				"serving":         true,
+				"transitions":     float64(0),
+				"last_transition": "1970-01-01T00:16:40Z",
+			},
+		},
+	}, raw)
+
+	var out MemLimiterStats
+	require.NoError(t, json.Unmarshal(data, &out))
+
+	in.SchemaVersion = SchemaVersion
+	require.Equal(t, in, &out)
+
+	t.Run("unknown zone", func(t *testing.T) {
+		require.Error(t, json.Unmarshal([]byte(`{"zone": "purple"}`), &MemoryBudgetStats{}))
+	})
+}
+
+func TestBackpressureStatsMerge(t *testing.T) {
+	out := &BackpressureStats{Throttling: &ThrottlingStats{Total: 1}}
+
+	out.Merge(&BackpressureStats{
+		Throttling:   &ThrottlingStats{Total: 2},
+		Cancellation: &CancellationStats{Cancelled: 3},
+	})
+	out.Merge(&BackpressureStats{Connections: &ConnectionsStats{Open: 4}})
+	out.Merge(nil)
+
+	require.Equal(t, &BackpressureStats{
+		Throttling:   &ThrottlingStats{Total: 1},
+		Cancellation: &CancellationStats{Cancelled: 3},
+		Connections:  &ConnectionsStats{Open: 4},
+	}, out)
+}
@@ -0,0 +1,24 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package stats
+
+import (
+	_ "embed"
+	"slices"
+)
+
+//go:generate go run ./internal/schemagen -dir . -out schema.json
+
+// schema is JSON Schema of MemLimiterStats generated from the source code.
+//
+//go:embed schema.json
+var schema []byte
+
+// JSONSchema returns JSON Schema (draft 2020-12) describing JSON representation of MemLimiterStats.
+func JSONSchema() []byte {
+	return slices.Clone(schema)
+}
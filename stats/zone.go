@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package stats
+
+import "fmt"
+
+// Zone - memory budget utilization zone determining controller behavior.
+type Zone int
+
+const (
+	// ZoneGreen - memory budget utilization is low, control parameters have default values.
+	ZoneGreen Zone = iota
+	// ZoneGOGC - memory budget utilization exceeds GOGC danger zone, GC is intensified.
+	ZoneGOGC
+	// ZoneThrottling - memory budget utilization exceeds throttling danger zone, requests are throttled.
+	ZoneThrottling
+	// ZoneCritical - memory budget is exhausted.
+	ZoneCritical
+)
+
+// String returns zone name.
+func (z Zone) String() string {
+	switch z {
+	case ZoneGreen:
+		return "green"
+	case ZoneGOGC:
+		return "gogc"
+	case ZoneThrottling:
+		return "throttling"
+	case ZoneCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes zone as its name.
+func (z Zone) MarshalText() ([]byte, error) {
+	return []byte(z.String()), nil
+}
+
+// UnmarshalText decodes zone from its name.
+func (z *Zone) UnmarshalText(text []byte) error {
+	for _, zone := range Zones() {
+		if zone.String() == string(text) {
+			*z = zone
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown zone '%s'", text)
+}
+
+// Zones returns all known zones in ascending order of memory budget utilization.
+func Zones() []Zone {
+	return []Zone{ZoneGreen, ZoneGOGC, ZoneThrottling, ZoneCritical}
+}
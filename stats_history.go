@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/newcloudtechnologies/memlimiter/utils"
+	"github.com/newcloudtechnologies/memlimiter/utils/breaker"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+)
+
+const (
+	// defaultStatsHistorySize is the default number of statistics samples kept.
+	defaultStatsHistorySize = 300
+	// defaultStatsHistoryInterval is the default interval between statistics samples.
+	defaultStatsHistoryInterval = time.Second
+)
+
+// StatsHistoryConfig - settings of the in-process statistics history (see Service.GetStatsHistory).
+// History is kept only if the config is set; default values keep the latest five minutes of statistics.
+type StatsHistoryConfig struct {
+	// Size - number of the latest samples kept. Zero means default value (300).
+	Size int `json:"size"`
+	// Interval - interval between samples. Zero means default value (1s).
+	Interval duration.Duration `json:"interval"`
+}
+
+// Prepare - config validator.
+func (c *StatsHistoryConfig) Prepare() error {
+	if c.Size < 0 || c.Interval.Duration < 0 {
+		return errors.New("negative Size or Interval")
+	}
+
+	if c.Size == 0 {
+		c.Size = defaultStatsHistorySize
+	}
+
+	if c.Interval.Duration == 0 {
+		c.Interval.Duration = defaultStatsHistoryInterval
+	}
+
+	return nil
+}
+
+// StatsSample - MemLimiter statistics at the particular moment.
+type StatsSample struct {
+	// Time - the moment of the sample.
+	Time time.Time `json:"time"`
+	// Stats - MemLimiter statistics.
+	Stats *stats.MemLimiterStats `json:"stats"`
+}
+
+// statsHistory periodically samples statistics into the ring buffer. It is safe for concurrent use.
+type statsHistory struct {
+	samples  *utils.Ring[*StatsSample]
+	interval time.Duration
+	// mutex protects samples.
+	mutex    sync.Mutex
+	getStats func() (*stats.MemLimiterStats, error)
+	breaker  *breaker.Breaker
+	logger   logr.Logger
+}
+
+func newStatsHistory(cfg *StatsHistoryConfig) *statsHistory {
+	size, interval := defaultStatsHistorySize, defaultStatsHistoryInterval
+	if cfg.Size > 0 {
+		size = cfg.Size
+	}
+
+	if cfg.Interval.Duration > 0 {
+		interval = cfg.Interval.Duration
+	}
+
+	return &statsHistory{
+		samples:  utils.NewRing[*StatsSample](size),
+		interval: interval,
+		breaker:  breaker.NewBreakerWithInitValue(1),
+	}
+}
+
+// start runs the sampling loop.
+func (h *statsHistory) start(logger logr.Logger, getStats func() (*stats.MemLimiterStats, error)) {
+	h.logger = logger
+	h.getStats = getStats
+
+	go h.loop()
+}
+
+func (h *statsHistory) loop() {
+	defer h.breaker.Dec()
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			memLimiterStats, err := h.getStats()
+			if err != nil {
+				h.logger.Error(err, "get stats for history")
+
+				continue
+			}
+
+			h.push(now, memLimiterStats)
+		case <-h.breaker.Done():
+			return
+		}
+	}
+}
+
+// push records sample.
+func (h *statsHistory) push(now time.Time, memLimiterStats *stats.MemLimiterStats) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.samples.Push(&StatsSample{Time: now, Stats: memLimiterStats})
+}
+
+// query returns the samples taken within [from; to] from the oldest to the newest;
+// zero from or to means the range is not bounded from the corresponding side.
+func (h *statsHistory) query(from, to time.Time) []*StatsSample {
+	h.mutex.Lock()
+	samples := h.samples.Values()
+	h.mutex.Unlock()
+
+	out := samples[:0]
+
+	for _, sample := range samples {
+		if (!from.IsZero() && sample.Time.Before(from)) || (!to.IsZero() && sample.Time.After(to)) {
+			continue
+		}
+
+		out = append(out, sample)
+	}
+
+	return out
+}
+
+// quit stops the sampling loop.
+func (h *statsHistory) quit() {
+	h.breaker.ShutdownAndWait()
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package memlimiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/newcloudtechnologies/memlimiter/stats"
+	"github.com/newcloudtechnologies/memlimiter/utils/config/duration"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsHistory(t *testing.T) {
+	t.Run("query", func(t *testing.T) {
+		h := newStatsHistory(&StatsHistoryConfig{Size: 3})
+		start := time.Unix(1000, 0)
+
+		for i := range 4 {
+			h.push(start.Add(time.Duration(i)*time.Second), &stats.MemLimiterStats{
+				Admission: &stats.AdmissionStats{Admitted: uint64(i)},
+			})
+		}
+
+		samples := h.query(time.Time{}, time.Time{})
+		require.Len(t, samples, 3)
+		require.Equal(t, start.Add(time.Second), samples[0].Time)
+
+		samples = h.query(start.Add(2*time.Second), start.Add(2*time.Second))
+		require.Len(t, samples, 1)
+		require.Equal(t, uint64(2), samples[0].Stats.Admission.Admitted)
+
+		require.Empty(t, h.query(start.Add(time.Minute), time.Time{}))
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		h := newStatsHistory(&StatsHistoryConfig{Size: 10, Interval: duration.Duration{Duration: time.Millisecond}})
+		h.start(testr.New(t), func() (*stats.MemLimiterStats, error) { return &stats.MemLimiterStats{}, nil })
+
+		defer h.quit()
+
+		require.Eventually(t, func() bool {
+			return len(h.query(time.Time{}, time.Time{})) == 10
+		}, 5*time.Second, time.Millisecond)
+	})
+}
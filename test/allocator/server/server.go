@@ -66,12 +66,10 @@ func NewServer(logger logr.Logger, cfg *Config, options ...grpc.ServerOption) (S
 		return nil, fmt.Errorf("new tracker from config: %w", err)
 	}
 
-	if cfg.MemLimiter != nil {
-		options = append(options,
-			grpc.UnaryInterceptor(memLimiter.Middleware().GRPC().MakeUnaryServerInterceptor()),
-			grpc.StreamInterceptor(memLimiter.Middleware().GRPC().MakeStreamServerInterceptor()),
-		)
-	}
+	options = append(options,
+		grpc.UnaryInterceptor(memLimiter.Middleware().GRPC().MakeUnaryServerInterceptor()),
+		grpc.StreamInterceptor(memLimiter.Middleware().GRPC().MakeStreamServerInterceptor()),
+	)
 
 	srv := &serverImpl{
 		logger:     logger,
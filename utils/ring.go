@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package utils
+
+// Ring is a fixed-size buffer keeping the latest values; the oldest value is overwritten
+// when the buffer is full. It is not safe for concurrent use.
+type Ring[T any] struct {
+	// values is the storage.
+	values []T
+	// next is the position of the next value.
+	next int
+	// full is set when the storage has been filled at least once.
+	full bool
+}
+
+// NewRing creates a ring keeping up to size values.
+func NewRing[T any](size int) *Ring[T] {
+	return &Ring[T]{values: make([]T, max(size, 1))}
+}
+
+// Push appends the value, overwriting the oldest one if the ring is full.
+func (r *Ring[T]) Push(value T) {
+	r.values[r.next] = value
+	r.next = (r.next + 1) % len(r.values)
+
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Len returns the number of values kept.
+func (r *Ring[T]) Len() int {
+	if r.full {
+		return len(r.values)
+	}
+
+	return r.next
+}
+
+// Values returns the copy of the values from the oldest to the newest.
+func (r *Ring[T]) Values() []T {
+	if !r.full {
+		return append([]T(nil), r.values[:r.next]...)
+	}
+
+	out := make([]T, 0, len(r.values))
+	out = append(out, r.values[r.next:]...)
+
+	return append(out, r.values[:r.next]...)
+}
@@ -0,0 +1,30 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRing(t *testing.T) {
+	r := NewRing[int](3)
+	require.Equal(t, 0, r.Len())
+	require.Empty(t, r.Values())
+
+	r.Push(1)
+	r.Push(2)
+	require.Equal(t, 2, r.Len())
+	require.Equal(t, []int{1, 2}, r.Values())
+
+	r.Push(3)
+	r.Push(4)
+	r.Push(5)
+	require.Equal(t, 3, r.Len())
+	require.Equal(t, []int{3, 4, 5}, r.Values())
+}
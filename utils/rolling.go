@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package utils
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// RollingCounter is a lock-free counter accumulating values over a sliding time window.
+//
+// The window is split into buckets of equal width (resolution). Every bucket remembers
+// the epoch (time divided by the resolution) it belongs to, so stale buckets are reset
+// lazily by the first writer of a new epoch. An increment racing with the bucket rotation
+// may be lost, which is acceptable for statistical purposes.
+// It is safe for concurrent use.
+type RollingCounter struct {
+	// buckets is the ring of buckets covering the whole window.
+	buckets []rollingBucket
+	// resolution is the width of a single bucket.
+	resolution time.Duration
+}
+
+// rollingBucket is a single slot of the rolling counter.
+// It must not be copied after first use because it contains atomic fields.
+type rollingBucket struct {
+	// epoch is the number of the time slot the bucket value belongs to.
+	epoch atomic.Int64
+	// value is the accumulated value.
+	value atomic.Uint64
+}
+
+// NewRollingCounter creates a counter keeping values for the given window
+// with the given resolution. The window is rounded up to the resolution.
+func NewRollingCounter(window, resolution time.Duration) *RollingCounter {
+	if resolution <= 0 {
+		resolution = time.Second
+	}
+
+	size := max(int((window+resolution-1)/resolution), 1)
+
+	out := &RollingCounter{
+		buckets:    make([]rollingBucket, size),
+		resolution: resolution,
+	}
+
+	// Mark all buckets as stale.
+	for i := range out.buckets {
+		out.buckets[i].epoch.Store(-1)
+	}
+
+	return out
+}
+
+// Inc adds the given value to the current bucket.
+func (c *RollingCounter) Inc(value uint64) {
+	c.incAt(time.Now(), value)
+}
+
+// Sum returns the total value accumulated during the given window.
+// Windows longer than the counter window are truncated.
+func (c *RollingCounter) Sum(window time.Duration) uint64 {
+	return c.sumAt(time.Now(), window)
+}
+
+// Window returns the longest window the counter can report on.
+func (c *RollingCounter) Window() time.Duration {
+	return c.resolution * time.Duration(len(c.buckets))
+}
+
+func (c *RollingCounter) incAt(now time.Time, value uint64) {
+	epoch := c.epoch(now)
+	bucket := &c.buckets[c.index(epoch)]
+
+	for {
+		old := bucket.epoch.Load()
+		if old == epoch {
+			break
+		}
+
+		// Clock went backwards or the writer is too slow: the bucket is already reused.
+		if old > epoch {
+			return
+		}
+
+		if bucket.epoch.CompareAndSwap(old, epoch) {
+			bucket.value.Store(0)
+
+			break
+		}
+	}
+
+	bucket.value.Add(value)
+}
+
+func (c *RollingCounter) sumAt(now time.Time, window time.Duration) uint64 {
+	count := min(int64((window+c.resolution-1)/c.resolution), int64(len(c.buckets)))
+	current := c.epoch(now)
+
+	var sum uint64
+
+	for epoch := current - count + 1; epoch <= current; epoch++ {
+		if epoch < 0 {
+			continue
+		}
+
+		bucket := &c.buckets[c.index(epoch)]
+		if bucket.epoch.Load() == epoch {
+			sum += bucket.value.Load()
+		}
+	}
+
+	return sum
+}
+
+func (c *RollingCounter) epoch(now time.Time) int64 {
+	return now.UnixNano() / int64(c.resolution)
+}
+
+func (c *RollingCounter) index(epoch int64) int {
+	return int(epoch % int64(len(c.buckets)))
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) New Cloud Technologies, Ltd. 2013-2026.
+ * Author: Vitaly Isaev <vitaly.isaev@myoffice.team>
+ * License: https://github.com/newcloudtechnologies/memlimiter/blob/master/LICENSE
+ */
+
+package utils
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollingCounter(t *testing.T) {
+	t.Run("sliding window", func(t *testing.T) {
+		c := NewRollingCounter(10*time.Second, time.Second)
+		require.Equal(t, 10*time.Second, c.Window())
+
+		start := time.Unix(1000, 0)
+
+		for i := range 10 {
+			c.incAt(start.Add(time.Duration(i)*time.Second), 1)
+		}
+
+		now := start.Add(9 * time.Second)
+		require.Equal(t, uint64(10), c.sumAt(now, 10*time.Second))
+		require.Equal(t, uint64(3), c.sumAt(now, 3*time.Second))
+		// Windows exceeding the counter capacity are truncated.
+		require.Equal(t, uint64(10), c.sumAt(now, time.Minute))
+
+		// Old buckets leave the window.
+		now = start.Add(14 * time.Second)
+		require.Equal(t, uint64(5), c.sumAt(now, 10*time.Second))
+
+		// Stale buckets are reset by the writers.
+		c.incAt(now, 7)
+		require.Equal(t, uint64(12), c.sumAt(now, 10*time.Second))
+		require.Equal(t, uint64(7), c.sumAt(now, time.Second))
+	})
+
+	t.Run("concurrent increments", func(t *testing.T) {
+		const (
+			workers    = 100
+			increments = 100
+		)
+
+		c := NewRollingCounter(2*time.Hour, time.Hour)
+
+		wg := &sync.WaitGroup{}
+
+		for range workers {
+			wg.Go(func() {
+				for range increments {
+					c.Inc(1)
+				}
+			})
+		}
+
+		wg.Wait()
+
+		require.Equal(t, uint64(workers*increments), c.Sum(2*time.Hour))
+	})
+}